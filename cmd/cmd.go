@@ -2,9 +2,13 @@ package cmd
 
 import (
 	"flag"
+	"fmt"
+	"push-sender/internal/admin"
 	"push-sender/internal/app"
 	"push-sender/internal/liveness"
+	"push-sender/internal/ratelimit"
 	"push-sender/internal/runner"
+	"push-sender/internal/templates"
 	"sync"
 
 	config "github.com/spf13/viper"
@@ -18,17 +22,41 @@ func init() {
 	config.SetDefault("liveness_enabled", false)
 }
 
+var commands = map[string]func(args []string) error{
+	"rustore-topic": runRustoreTopic,
+	"apns-channel":  runApnsChannel,
+	"suppress":      runSuppress,
+}
+
+func runCommand(args []string) error {
+	command, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %s", args[0])
+	}
+	return command(args[1:])
+}
+
 func Run() error {
 	flag.Parse()
 	ctx := runner.NewDefaultRunner(
 		*configFileName,
-		map[string]func(param string){},
+		map[string]func(param string){
+			"templates": templates.Reload,
+			"ratelimit": ratelimit.Reload,
+			"pools":     app.Reload,
+		},
 	).StartAsync()
 
+	if flag.NArg() > 0 {
+		return runCommand(flag.Args())
+	}
+
 	var wg sync.WaitGroup
 
 	liveness.NewDefaultLiveness().Start(ctx, &wg)
 
+	admin.NewDefaultAdmin().Start(ctx, &wg)
+
 	app.NewDefaultApp().Start(ctx, &wg)
 
 	wg.Wait()
@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"push-sender/internal/push/ios"
+	"push-sender/internal/transport"
+)
+
+// apns-channel create|delete|list <bundle> [channel]
+func runApnsChannel(args []string) error {
+	if len(args) < 2 {
+		return errors.New("usage: apns-channel create|delete|list <bundle> [channel]")
+	}
+
+	sender := ios.New()
+	opts := transport.NewDefaultApnsConfig().GetConfig(args[1])
+
+	switch args[0] {
+	case "create":
+		channelId, err := sender.CreateChannel(ios.ChannelMostRecent, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Println(channelId)
+		return nil
+	case "delete":
+		if len(args) < 3 {
+			return errors.New("usage: apns-channel delete <bundle> <channel>")
+		}
+		return sender.DeleteChannel(args[2], opts)
+	case "list":
+		channels, err := sender.ListChannels(opts)
+		if err != nil {
+			return err
+		}
+		for _, channelId := range channels {
+			fmt.Println(channelId)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("apns-channel: unknown action %s", args[0])
+}
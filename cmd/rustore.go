@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"push-sender/internal/push/rustore"
+	"push-sender/internal/transport"
+)
+
+// rustore-topic subscribe|unsubscribe <project> <topic> <token> [token...]
+func runRustoreTopic(args []string) error {
+	if len(args) < 4 {
+		return errors.New("usage: rustore-topic subscribe|unsubscribe <project> <topic> <token> [token...]")
+	}
+
+	opts := transport.NewDefaultRustoreConfig().GetConfig(args[1])
+
+	switch args[0] {
+	case "subscribe":
+		return rustore.Subscribe(args[2], args[3:], opts)
+	case "unsubscribe":
+		return rustore.Unsubscribe(args[2], args[3:], opts)
+	}
+
+	return fmt.Errorf("rustore-topic: unknown action %s", args[0])
+}
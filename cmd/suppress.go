@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/goccy/go-json"
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/suppress"
+	"push-sender/internal/task"
+)
+
+// suppress list [project]
+// suppress add <project> <platform> <to> [ttl]
+// suppress remove <project> <platform> <to>
+//
+// talks to admin api of running sender with admin_token, admin_url defaults to admin port
+func runSuppress(args []string) error {
+	usage := errors.New("usage: suppress list [project] | add <project> <platform> <to> [ttl] | remove <project> <platform> <to>")
+	if len(args) < 1 {
+		return usage
+	}
+
+	endpoint := adminUrl() + suppress.AdminPath
+
+	var (
+		request *http.Request
+		err     error
+	)
+
+	switch {
+	case args[0] == "list":
+		q := url.Values{}
+		if len(args) > 1 {
+			q.Set("project", args[1])
+		}
+		request, err = http.NewRequest(http.MethodGet, endpoint+"?"+q.Encode(), nil)
+	case args[0] == "add" && len(args) >= 4:
+		req := suppress.AddRequest{}
+		req.Project, req.Platform, req.To = args[1], task.Platform(args[2]), args[3]
+		if len(args) > 4 {
+			req.Ttl = args[4]
+		}
+		body, _ := json.Marshal(&req)
+		request, err = http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(body))
+	case args[0] == "remove" && len(args) >= 4:
+		q := url.Values{}
+		q.Set("project", args[1])
+		q.Set("platform", args[2])
+		q.Set("to", args[3])
+		request, err = http.NewRequest(http.MethodDelete, endpoint+"?"+q.Encode(), nil)
+	default:
+		return usage
+	}
+
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Authorization", "Bearer "+config.GetString("admin_token"))
+
+	resp, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("suppress: %s %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	_, err = io.Copy(os.Stdout, resp.Body)
+	return err
+}
+
+func adminUrl() string {
+	if u := config.GetString("admin_url"); u != "" {
+		return strings.TrimSuffix(u, "/")
+	}
+	addr := config.GetString("admin_port")
+	if strings.HasPrefix(addr, ":") {
+		addr = "localhost" + addr
+	}
+	return "http://" + addr
+}
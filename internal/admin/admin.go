@@ -0,0 +1,94 @@
+package admin
+
+/**
+ *	Admin api listener, kept apart from public liveness port. Every call
+ *	requires "Authorization: Bearer <admin_token>", listener is not started
+ *	while token is empty.
+ *
+ *	admin_port: 127.0.0.1:8081
+ *	admin_token: secret
+ */
+
+import (
+	"context"
+	"crypto/subtle"
+	"expvar"
+	"net/http"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+var mux = http.NewServeMux()
+
+func init() {
+	config.SetDefault("admin_port", "127.0.0.1:8081")
+	config.SetDefault("admin_token", "")
+
+	// expvar registers on default mux too, liveness does not serve it
+	mux.Handle("/debug/vars", expvar.Handler())
+}
+
+// HandleFunc registers admin handler, it is served behind token check
+func HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	mux.HandleFunc(pattern, handler)
+}
+
+// Handler returns admin mux behind token check
+func Handler() http.Handler {
+	return authorize(config.GetString("admin_token"), mux)
+}
+
+func authorize(token string, next http.Handler) http.Handler {
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" || subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type Admin interface {
+	Start(ctx context.Context, wg *sync.WaitGroup)
+}
+
+type defaultAdmin struct {
+}
+
+func (da defaultAdmin) Start(ctx context.Context, wg *sync.WaitGroup) {
+	if config.GetString("admin_token") == "" {
+		log.Warnf("admin: admin_token is empty, admin api is disabled")
+		return
+	}
+
+	srv := http.Server{
+		Addr:    config.GetString("admin_port"),
+		Handler: Handler(),
+	}
+
+	wg.Add(2)
+
+	go func(ctx context.Context) {
+		defer wg.Done()
+		<-ctx.Done()
+		if err := srv.Shutdown(context.Background()); err != nil {
+			log.Errorf("admin: cannot shutdown server %s", err)
+			return
+		}
+	}(ctx)
+
+	go func() {
+		defer wg.Done()
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			log.Fatalf("admin: HTTP server ListenAndServe: %v", err)
+		}
+	}()
+}
+
+func NewDefaultAdmin() Admin {
+	return defaultAdmin{}
+}
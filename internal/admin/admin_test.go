@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuthorize(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	cases := []struct {
+		token  string
+		header string
+		code   int
+	}{
+		{"secret", "Bearer secret", http.StatusOK},
+		{"secret", "Bearer other", http.StatusUnauthorized},
+		{"secret", "", http.StatusUnauthorized},
+		{"", "", http.StatusUnauthorized},
+		{"", "Bearer ", http.StatusUnauthorized},
+	}
+
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/admin/suppressions", nil)
+		if c.header != "" {
+			r.Header.Set("Authorization", c.header)
+		}
+		w := httptest.NewRecorder()
+		authorize(c.token, ok).ServeHTTP(w, r)
+		if w.Code != c.code {
+			t.Errorf("token %q header %q: expected %d got %d", c.token, c.header, c.code, w.Code)
+		}
+	}
+}
+
+func TestExpvar(t *testing.T) {
+	srv := httptest.NewServer(authorize("secret", mux))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/debug/vars", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expvar is not served %v %v", resp, err)
+	}
+	resp.Body.Close()
+}
@@ -2,9 +2,18 @@ package app
 
 import (
 	"context"
+	"errors"
+	"push-sender/internal/concurrency"
+	"push-sender/internal/feedback"
 	"push-sender/internal/fetcher"
+	"push-sender/internal/push"
+	"push-sender/internal/ratelimit"
+	"push-sender/internal/results"
+	"push-sender/internal/schedule"
+	"push-sender/internal/suppress"
 	"push-sender/internal/task"
 	"sync"
+	"time"
 
 	"push-sender/internal/worker"
 
@@ -12,53 +21,172 @@ import (
 	config "github.com/spf13/viper"
 )
 
+func init() {
+	// release delay of task whose pool has no budget left
+	config.SetDefault("pools_release_delay", time.Second)
+}
+
 type Application interface {
 	Start(ctx context.Context, wg *sync.WaitGroup)
 }
 
+var reloads = make(chan struct{}, 1)
+
+// Reload resizes worker pools of running application, used as runner config change callback
+func Reload(_ string) {
+	select {
+	case reloads <- struct{}{}:
+	default:
+	}
+}
+
 type defaultApplication struct {
-	channelTask chan *task.Task
-	fetch       fetcher.Fetcher
-	workers     map[int]worker.Worker
+	fetch fetcher.Fetcher
+	env   *worker.Env
+
+	// guards pools against reload while dispatching
+	mutex     sync.RWMutex
+	pools     map[string]*pool
+	routes    []*pool
+	workersWg sync.WaitGroup
 }
 
 func (da *defaultApplication) startFetcher(ctx context.Context, wg *sync.WaitGroup) {
 	defer wg.Done()
+	defer da.closePools()
 	for {
-		mtask, err := da.fetch.Get()
-		if err != nil && err != fetcher.ErrContinue {
-			log.Errorf("cannot fetch data")
-			break
-		}
 		select {
 		case <-ctx.Done():
-			close(da.channelTask)
 			log.Debug("graceful shutdown fetcher")
-			break
+			return
+		default:
+		}
+
+		mtask, err := da.fetch.Get()
+		if err == fetcher.ErrContinue {
+			continue
+		}
+		var malformed *fetcher.MalformedError
+		if errors.As(err, &malformed) {
+			log.Errorf("cannot parse task %s", err)
+			da.env.Results.Put(results.NewResult(malformed.Task, 0, push.ErrorRequest, time.Now()))
+			continue
+		}
+		if err != nil {
+			log.Errorf("cannot fetch data %s", err)
+			continue
+		}
+
+		log.Debugf("get task %v", mtask)
+
+		// scheduled task goes back to queue, no worker waits for it
+		if delay := schedule.Delay(mtask, time.Now()); delay > 0 {
+			log.Debugf("task %d deferred for %s", mtask.ID, delay)
+			if err := da.fetch.Release(mtask, delay); err != nil {
+				log.Errorf("cannot defer task %d %s", mtask.ID, err)
+			}
+			continue
+		}
+
+		if !da.dispatch(ctx, mtask) {
+			log.Debug("graceful shutdown fetcher")
+			return
+		}
+	}
+}
+
+// dispatch hands task to its pool. Default pool is waited for, dedicated pool
+// without budget gets task released so other platforms are not blocked.
+func (da *defaultApplication) dispatch(ctx context.Context, mtask *task.Task) bool {
+	da.mutex.RLock()
+	defer da.mutex.RUnlock()
+
+	p := da.route(mtask)
+	channel := p.channel(mtask)
+
+	if p.name != defaultPool {
+		select {
+		case channel <- mtask:
 		default:
-			log.Debugf("get task %v", mtask)
+			log.Debugf("pool %s is busy, release task %d", p.name, mtask.ID)
+			if err := da.fetch.Release(mtask, config.GetDuration("pools_release_delay")); err != nil {
+				log.Errorf("cannot release task %d %s", mtask.ID, err)
+			}
+		}
+		return true
+	}
+
+	select {
+	case channel <- mtask:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (da *defaultApplication) closePools() {
+	da.mutex.Lock()
+	defer da.mutex.Unlock()
+	for name, p := range da.pools {
+		p.close()
+		delete(da.pools, name)
+	}
+	da.routes = nil
+}
+
+func (da *defaultApplication) watchReload(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-reloads:
+			da.mutex.Lock()
+			// pools are closed on shutdown
+			if len(da.pools) > 0 {
+				da.applyPools()
+			}
+			da.mutex.Unlock()
 		}
 	}
 }
 
 func (da *defaultApplication) Start(ctx context.Context, wg *sync.WaitGroup) {
-	wg.Add(1)
 	da.fetch = fetcher.NewDefaultFetcher(ctx)
+	da.env.Source = da.fetch
+
+	da.mutex.Lock()
+	da.applyPools()
+	da.mutex.Unlock()
+
+	wg.Add(2)
 	go da.startFetcher(ctx, wg)
-	wg.Add(config.GetInt("worker_count"))
-	for _, v := range da.workers {
-		v.Start(da.channelTask, wg)
-	}
+	go da.watchReload(ctx, wg)
+
+	// results and feedback are flushed once the last worker is done
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-ctx.Done()
+		da.workersWg.Wait()
+		da.env.Results.Close()
+		da.env.Feedback.Close()
+	}()
 }
 
 func NewDefaultApp() Application {
 	app := &defaultApplication{
-		channelTask: make(chan *task.Task),
-		workers:     make(map[int]worker.Worker, config.GetInt("worker_count")),
-	}
-	for i := 0; i < config.GetInt("worker_count"); i++ {
-		app.workers[i] = worker.NewDefaultWorker()
+		pools: make(map[string]*pool),
+		env: &worker.Env{
+			Results:     results.NewDefaultSink(),
+			Feedback:    feedback.NewDefaultFeedback(),
+			Suppress:    suppress.NewDefaultStore(),
+			Limiter:     ratelimit.Default(),
+			Concurrency: concurrency.Default(),
+		},
 	}
+	suppress.RegisterHandlers(app.env.Suppress)
+	concurrency.RegisterHandlers(app.env.Concurrency)
 
 	return app
 }
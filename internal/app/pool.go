@@ -0,0 +1,196 @@
+package app
+
+import (
+	"sort"
+	"sync"
+
+	"push-sender/internal/containers/slices"
+	"push-sender/internal/lanes"
+	"push-sender/internal/task"
+	"push-sender/internal/worker"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+const defaultPool = "default"
+
+// pool is a group of workers with own channel, configured as
+//
+//	pools:
+//	  apns:
+//	    platforms: [ios]
+//	    workers: 10
+//	    budget: 100      # tasks taken from queue and waiting for workers, set on pool creation
+//	    reserved: 2      # additional workers serving high priority lanes only
+//	  shop_android:
+//	    platforms: [android]
+//	    projects: [shop]
+//	    workers: 4
+//
+// tasks matching no pool are processed by worker_count and reserved_workers default workers
+type pool struct {
+	name      string
+	platforms []string
+	projects  []string
+	high      chan *task.Task
+	normal    chan *task.Task
+	env       *worker.Env
+	wg        *sync.WaitGroup
+	// quit channel of every running worker
+	quits         []chan struct{}
+	reservedQuits []chan struct{}
+}
+
+func newPool(name string, budget int, env *worker.Env, wg *sync.WaitGroup) *pool {
+	return &pool{
+		name:   name,
+		high:   make(chan *task.Task, budget),
+		normal: make(chan *task.Task, budget),
+		env:    env,
+		wg:     wg,
+	}
+}
+
+// channel returns pool channel of task lane
+func (p *pool) channel(qtask *task.Task) chan *task.Task {
+	if lanes.IsHigh(qtask.Lane) {
+		return p.high
+	}
+	return p.normal
+}
+
+// match reports pool serves task, empty lists match everything
+func (p *pool) match(qtask *task.Task) bool {
+	return (len(p.platforms) == 0 || slices.Contains(p.platforms, string(qtask.Type))) &&
+		(len(p.projects) == 0 || slices.Contains(p.projects, qtask.Project))
+}
+
+func (p *pool) resize(size int, reserved int) {
+	if size < 1 {
+		size = 1
+	}
+	if reserved < 0 {
+		reserved = 0
+	}
+
+	p.quits = p.scale(p.quits, size, worker.Channels{High: p.high, Normal: p.normal})
+	p.reservedQuits = p.scale(p.reservedQuits, reserved, worker.Channels{High: p.high})
+}
+
+func (p *pool) scale(quits []chan struct{}, size int, channels worker.Channels) []chan struct{} {
+	for len(quits) < size {
+		quit := make(chan struct{})
+		p.wg.Add(1)
+		worker.NewDefaultWorker(p.env).Start(channels, quit, p.wg)
+		quits = append(quits, quit)
+	}
+
+	for len(quits) > size {
+		last := len(quits) - 1
+		close(quits[last])
+		quits = quits[:last]
+	}
+
+	return quits
+}
+
+// close lets workers finish queued tasks and exit
+func (p *pool) close() {
+	close(p.high)
+	close(p.normal)
+	p.quits = nil
+	p.reservedQuits = nil
+}
+
+type poolConfig struct {
+	name      string
+	platforms []string
+	projects  []string
+	workers   int
+	reserved  int
+	budget    int
+}
+
+func readPoolConfigs() []poolConfig {
+	configs := []poolConfig{{
+		name:     defaultPool,
+		workers:  config.GetInt("worker_count"),
+		reserved: config.GetInt("reserved_workers"),
+	}}
+
+	for name := range config.GetStringMap("pools") {
+		if name == defaultPool {
+			log.Errorf("app: pool name %s is reserved", name)
+			continue
+		}
+		prefix := "pools." + name + "."
+		c := poolConfig{
+			name:      name,
+			platforms: config.GetStringSlice(prefix + "platforms"),
+			projects:  config.GetStringSlice(prefix + "projects"),
+			workers:   config.GetInt(prefix + "workers"),
+			reserved:  config.GetInt(prefix + "reserved"),
+			budget:    config.GetInt(prefix + "budget"),
+		}
+		if c.budget <= 0 {
+			c.budget = c.workers
+		}
+		configs = append(configs, c)
+	}
+
+	return configs
+}
+
+// applyPools creates, resizes and removes pools, caller holds da.mutex
+func (da *defaultApplication) applyPools() {
+	configured := make(map[string]bool)
+
+	for _, c := range readPoolConfigs() {
+		configured[c.name] = true
+
+		p, ok := da.pools[c.name]
+		if !ok {
+			p = newPool(c.name, c.budget, da.env, &da.workersWg)
+			da.pools[c.name] = p
+		}
+		p.platforms = c.platforms
+		p.projects = c.projects
+		p.resize(c.workers, c.reserved)
+
+		log.Infof("app: pool %s platforms %v projects %v workers %d reserved %d",
+			c.name, c.platforms, c.projects, len(p.quits), len(p.reservedQuits))
+	}
+
+	for name, p := range da.pools {
+		if !configured[name] {
+			log.Infof("app: pool %s removed", name)
+			p.close()
+			delete(da.pools, name)
+		}
+	}
+
+	// project pools are more specific than platform ones
+	da.routes = da.routes[:0]
+	for name, p := range da.pools {
+		if name != defaultPool {
+			da.routes = append(da.routes, p)
+		}
+	}
+	sort.Slice(da.routes, func(i, j int) bool {
+		if (len(da.routes[i].projects) > 0) != (len(da.routes[j].projects) > 0) {
+			return len(da.routes[i].projects) > 0
+		}
+		return da.routes[i].name < da.routes[j].name
+	})
+}
+
+// route returns pool of task, caller holds da.mutex
+func (da *defaultApplication) route(qtask *task.Task) *pool {
+	for _, p := range da.routes {
+		if p.match(qtask) {
+			return p
+		}
+	}
+	return da.pools[defaultPool]
+}
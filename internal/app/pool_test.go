@@ -0,0 +1,66 @@
+package app
+
+import (
+	"testing"
+
+	"push-sender/internal/task"
+	"push-sender/internal/worker"
+
+	config "github.com/spf13/viper"
+)
+
+func TestPools(t *testing.T) {
+	config.Set("worker_count", 2)
+	config.Set("pools", map[string]any{
+		"apns": map[string]any{
+			"platforms": []string{"ios"},
+			"workers":   3,
+			"reserved":  1,
+		},
+		"shop": map[string]any{
+			"platforms": []string{"ios", "android"},
+			"projects":  []string{"shop"},
+			"workers":   1,
+		},
+	})
+	defer config.Set("pools", nil)
+
+	da := &defaultApplication{
+		pools: make(map[string]*pool),
+		env:   &worker.Env{},
+	}
+	da.applyPools()
+
+	routes := map[string]*task.Task{
+		"apns":      {Type: task.Ios, Project: "news"},
+		"shop":      {Type: task.Ios, Project: "shop"},
+		defaultPool: {Type: task.Huawei, Project: "shop"},
+	}
+	for name, qtask := range routes {
+		if p := da.route(qtask); p.name != name {
+			t.Fatalf("task %#v routed to %s instead of %s", qtask, p.name, name)
+		}
+	}
+
+	if len(da.pools["apns"].quits) != 3 || len(da.pools["apns"].reservedQuits) != 1 || len(da.pools[defaultPool].quits) != 2 {
+		t.Fatalf("bad pool sizes")
+	}
+
+	config.Set("pools", map[string]any{
+		"apns": map[string]any{
+			"platforms": []string{"ios"},
+			"workers":   1,
+		},
+	})
+	da.applyPools()
+
+	if len(da.pools["apns"].quits) != 1 || len(da.pools["apns"].reservedQuits) != 0 || da.pools["shop"] != nil {
+		t.Fatalf("pools not resized")
+	}
+	if p := da.route(routes["shop"]); p.name != "apns" {
+		t.Fatalf("removed pool still routed")
+	}
+
+	da.closePools()
+	da.workersWg.Wait()
+}
@@ -0,0 +1,189 @@
+package breaker
+
+/**
+ *	Circuit breaker: opens when share of provider outage errors in window
+ *	exceeds error_ratio, lets half_open_requests trial sends through after
+ *	open_timeout and closes on their success
+ */
+
+import (
+	"sync"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return "closed"
+}
+
+func init() {
+	config.SetDefault("breaker.enabled", true)
+	config.SetDefault("breaker.window", 30*time.Second)
+	config.SetDefault("breaker.min_requests", 20)
+	config.SetDefault("breaker.error_ratio", 0.5)
+	config.SetDefault("breaker.open_timeout", 30*time.Second)
+	config.SetDefault("breaker.half_open_requests", 1)
+}
+
+// IsFailure reports error means provider outage, not a problem of the message
+func IsFailure(err error) bool {
+	return push.ErrorCode(err) == "ServiceUnavailable"
+}
+
+type Breaker struct {
+	name  string
+	mutex sync.Mutex
+	state State
+
+	windowStart time.Time
+	requests    int
+	failures    int
+
+	openedAt time.Time
+	trials   int
+}
+
+func New(name string) *Breaker {
+	return &Breaker{
+		name:        name,
+		windowStart: time.Now(),
+	}
+}
+
+// Allow returns done callback for permitted request or time left until trial
+func (b *Breaker) Allow() (done func(err error), wait time.Duration, ok bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	now := time.Now()
+
+	if b.state == Open {
+		left := config.GetDuration("breaker.open_timeout") - now.Sub(b.openedAt)
+		if left > 0 {
+			return nil, left, false
+		}
+		b.setState(HalfOpen)
+		b.trials = 0
+	}
+
+	if b.state == HalfOpen {
+		if b.trials >= config.GetInt("breaker.half_open_requests") {
+			return nil, config.GetDuration("breaker.open_timeout"), false
+		}
+		b.trials++
+		return b.trialDone, 0, true
+	}
+
+	if now.Sub(b.windowStart) > config.GetDuration("breaker.window") {
+		b.windowStart = now
+		b.requests = 0
+		b.failures = 0
+	}
+
+	return b.done, 0, true
+}
+
+func (b *Breaker) done(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// trial in flight while window was reopened
+	if b.state != Closed {
+		return
+	}
+
+	b.requests++
+	if IsFailure(err) {
+		b.failures++
+	}
+
+	if b.requests >= config.GetInt("breaker.min_requests") &&
+		float64(b.failures)/float64(b.requests) >= config.GetFloat64("breaker.error_ratio") {
+		b.open()
+	}
+}
+
+func (b *Breaker) trialDone(err error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.state != HalfOpen {
+		return
+	}
+
+	if IsFailure(err) {
+		b.open()
+		return
+	}
+
+	b.setState(Closed)
+	b.windowStart = time.Now()
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *Breaker) open() {
+	b.setState(Open)
+	b.openedAt = time.Now()
+}
+
+func (b *Breaker) setState(state State) {
+	if b.state != state {
+		log.Warnf("breaker: %s %s -> %s", b.name, b.state, state)
+		b.state = state
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.state
+}
+
+// Registry keeps breakers shared by all workers
+type Registry struct {
+	mutex    sync.Mutex
+	breakers map[string]*Breaker
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+func Default() *Registry {
+	return defaultRegistry
+}
+
+func (r *Registry) Get(name string) *Breaker {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	b, ok := r.breakers[name]
+	if !ok {
+		b = New(name)
+		r.breakers[name] = b
+	}
+	return b
+}
@@ -0,0 +1,64 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"push-sender/internal/push"
+
+	config "github.com/spf13/viper"
+)
+
+func TestBreaker(t *testing.T) {
+	config.Set("breaker.min_requests", 4)
+	config.Set("breaker.open_timeout", 20*time.Millisecond)
+	defer config.Set("breaker.min_requests", 20)
+	defer config.Set("breaker.open_timeout", 30*time.Second)
+
+	b := New("ios/p")
+
+	send := func(err error) {
+		done, _, ok := b.Allow()
+		if !ok {
+			t.Fatalf("request refused in %s", b.State())
+		}
+		done(err)
+	}
+
+	send(nil)
+	send(push.ErrorTokenRemoved)
+	send(push.ErrorTransportProblem)
+	if b.State() != Closed {
+		t.Fatalf("opened before min requests")
+	}
+	send(push.ErrorServiceUnavailable)
+	if b.State() != Open {
+		t.Fatalf("not opened at error ratio")
+	}
+
+	if _, wait, ok := b.Allow(); ok || wait <= 0 {
+		t.Fatalf("open breaker allows")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+
+	// single trial, failed trial reopens
+	done, _, ok := b.Allow()
+	if !ok || b.State() != HalfOpen {
+		t.Fatalf("trial not allowed")
+	}
+	if _, _, ok := b.Allow(); ok {
+		t.Fatalf("second trial allowed")
+	}
+	done(push.ErrorServiceUnavailable)
+	if b.State() != Open {
+		t.Fatalf("failed trial did not reopen")
+	}
+
+	time.Sleep(25 * time.Millisecond)
+
+	send(nil)
+	if b.State() != Closed {
+		t.Fatalf("successful trial did not close")
+	}
+}
@@ -0,0 +1,32 @@
+package concurrency
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/goccy/go-json"
+
+	"push-sender/internal/admin"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const AdminPath = "/admin/concurrency"
+
+// RegisterHandlers serves limits on admin listener and publishes them to expvar /debug/vars
+func RegisterHandlers(c *Controller) {
+	expvar.Publish("concurrency", expvar.Func(func() any {
+		return c.Stats()
+	}))
+
+	admin.HandleFunc(AdminPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c.Stats()); err != nil {
+			log.Errorf("concurrency: cannot write reply %s", err)
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package concurrency
+
+/**
+ *	AIMD limit of in-flight sends per platform and project: the limit grows
+ *	by one per round of successful sends while latency is stable and is cut
+ *	by backoff factor when provider throttles or fails with 5xx
+ */
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+
+	config "github.com/spf13/viper"
+)
+
+func init() {
+	config.SetDefault("concurrency.enabled", false)
+	config.SetDefault("concurrency.initial", 4)
+	config.SetDefault("concurrency.min", 1)
+	config.SetDefault("concurrency.max", 64)
+	// multiplicative decrease on overload
+	config.SetDefault("concurrency.backoff", 0.5)
+	// latency above average * tolerance stops growth
+	config.SetDefault("concurrency.tolerance", 2.0)
+	// release delay of task refused by limit
+	config.SetDefault("concurrency.retry_delay", time.Second)
+}
+
+// ewma weight of the last latency sample
+const alpha = 0.2
+
+type controller struct {
+	mutex        sync.Mutex
+	limit        float64
+	inFlight     int
+	latency      time.Duration
+	lastDecrease time.Time
+}
+
+func newController() *controller {
+	return &controller{
+		limit: config.GetFloat64("concurrency.initial"),
+	}
+}
+
+func (c *controller) acquire() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.inFlight >= int(math.Max(1, c.limit)) {
+		return false
+	}
+	c.inFlight++
+	return true
+}
+
+func (c *controller) done(err error, latency time.Duration, cancel bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	saturated := c.inFlight >= int(c.limit)
+	c.inFlight--
+
+	if cancel {
+		return
+	}
+
+	if push.IsOverload(err) {
+		// one decrease per round trip, requests in flight fail together
+		if time.Since(c.lastDecrease) > c.latency {
+			c.limit = math.Max(config.GetFloat64("concurrency.min"), c.limit*config.GetFloat64("concurrency.backoff"))
+			c.lastDecrease = time.Now()
+		}
+		return
+	}
+
+	if err != nil {
+		return
+	}
+
+	stable := c.latency == 0 || float64(latency) <= float64(c.latency)*config.GetFloat64("concurrency.tolerance")
+
+	if c.latency == 0 {
+		c.latency = latency
+	} else {
+		c.latency = time.Duration(alpha*float64(latency) + (1-alpha)*float64(c.latency))
+	}
+
+	if stable && saturated {
+		c.limit = math.Min(config.GetFloat64("concurrency.max"), c.limit+1/c.limit)
+	}
+}
+
+// Slot is one in-flight send
+type Slot struct {
+	c       *controller
+	started time.Time
+}
+
+// Done reports send outcome, latency is measured from acquire
+func (s *Slot) Done(err error) {
+	if s.c != nil {
+		s.c.done(err, time.Since(s.started), false)
+	}
+}
+
+// Cancel frees slot of send that did not happen
+func (s *Slot) Cancel() {
+	if s.c != nil {
+		s.c.done(nil, 0, true)
+	}
+}
+
+type Stats struct {
+	Limit     float64 `json:"limit"`
+	InFlight  int     `json:"in_flight"`
+	LatencyMs int64   `json:"latency_ms"`
+}
+
+type Controller struct {
+	mutex       sync.Mutex
+	controllers map[string]*controller
+}
+
+func New() *Controller {
+	return &Controller{
+		controllers: make(map[string]*controller),
+	}
+}
+
+var defaultController = New()
+
+func Default() *Controller {
+	return defaultController
+}
+
+func key(project string, platform task.Platform) string {
+	return string(platform) + "/" + project
+}
+
+// Acquire takes slot for send to platform of project, false if limit is reached
+func (c *Controller) Acquire(project string, platform task.Platform) (*Slot, bool) {
+	if !config.GetBool("concurrency.enabled") {
+		return &Slot{}, true
+	}
+
+	k := key(project, platform)
+
+	c.mutex.Lock()
+	ctl, ok := c.controllers[k]
+	if !ok {
+		ctl = newController()
+		c.controllers[k] = ctl
+	}
+	c.mutex.Unlock()
+
+	if !ctl.acquire() {
+		return nil, false
+	}
+
+	return &Slot{c: ctl, started: time.Now()}, true
+}
+
+// Stats returns current limits by "platform/project"
+func (c *Controller) Stats() map[string]Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	stats := make(map[string]Stats, len(c.controllers))
+	for k, ctl := range c.controllers {
+		ctl.mutex.Lock()
+		stats[k] = Stats{
+			Limit:     math.Round(ctl.limit*100) / 100,
+			InFlight:  ctl.inFlight,
+			LatencyMs: ctl.latency.Milliseconds(),
+		}
+		ctl.mutex.Unlock()
+	}
+	return stats
+}
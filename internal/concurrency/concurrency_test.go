@@ -0,0 +1,67 @@
+package concurrency
+
+import (
+	"testing"
+
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+
+	config "github.com/spf13/viper"
+)
+
+func TestAIMD(t *testing.T) {
+	config.Set("concurrency.enabled", true)
+	config.Set("concurrency.initial", 2)
+	defer config.Set("concurrency.enabled", false)
+	defer config.Set("concurrency.initial", 4)
+
+	c := New()
+
+	s1, ok1 := c.Acquire("p", task.Android)
+	s2, ok2 := c.Acquire("p", task.Android)
+	if !ok1 || !ok2 {
+		t.Fatalf("initial slots not given")
+	}
+	if _, ok := c.Acquire("p", task.Android); ok {
+		t.Fatalf("limit not applied")
+	}
+	if s, ok := c.Acquire("p", task.Ios); !ok {
+		t.Fatalf("other platform limited")
+	} else {
+		s.Cancel()
+	}
+
+	// saturated successes grow limit additively
+	s1.Done(nil)
+	s2.Done(nil)
+	limit := c.Stats()["android/p"].Limit
+	if limit <= 2 || limit >= 3 {
+		t.Fatalf("bad additive increase %v", limit)
+	}
+
+	s, _ := c.Acquire("p", task.Android)
+	s.Done(push.ErrorRateLimit)
+	if stats := c.Stats()["android/p"]; stats.Limit >= limit/2+0.01 || stats.InFlight != 0 {
+		t.Fatalf("bad multiplicative decrease %#v", stats)
+	}
+
+	// permanent errors keep limit
+	limit = c.Stats()["android/p"].Limit
+	s, _ = c.Acquire("p", task.Android)
+	s.Done(push.ErrorTokenRemoved)
+	if c.Stats()["android/p"].Limit != limit {
+		t.Fatalf("limit changed on token error")
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	c := New()
+	for i := 0; i < 100; i++ {
+		if _, ok := c.Acquire("p", task.Android); !ok {
+			t.Fatalf("disabled controller limits")
+		}
+	}
+	if len(c.Stats()) != 0 {
+		t.Fatalf("disabled controller tracks")
+	}
+}
@@ -19,3 +19,12 @@ func Grep[T ~[]V, V any](s T, callback func(v V) bool) T {
 	}
 	return res
 }
+
+func Contains[T ~[]V, V comparable](s T, value V) bool {
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,267 @@
+package feedback
+
+/**
+ *	Stream of tokens reported invalid or replaced by providers, consumed by device registry
+ */
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"push-sender/internal/push"
+	"push-sender/internal/spool"
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+const (
+	SinkTarantool = "tarantool"
+	SinkWebhook   = "webhook"
+	SinkFile      = "file"
+)
+
+func init() {
+	config.SetDefault("feedback.sink", "")
+	// repeated reports of a token within window are dropped, 0 disables dedup
+	config.SetDefault("feedback.dedup_window", time.Hour)
+	config.SetDefault("feedback.buffer", 1000)
+	// events failed to write are spooled and retried in order
+	config.SetDefault("feedback.spool_file", filepath.Join(os.TempDir(), "push-sender-feedback.spool"))
+	config.SetDefault("feedback.retry_interval", 10*time.Second)
+}
+
+type Event struct {
+	Project  string        `json:"project"`
+	Platform task.Platform `json:"platform"`
+	Token    string        `json:"token"`
+	Reason   string        `json:"reason"`
+	At       time.Time     `json:"at"`
+	// token to use instead of Token, set for Canonical reason
+	Replacement string `json:"replacement,omitempty"`
+}
+
+func (e *Event) Tuple() []any {
+	return []any{e.Project, string(e.Platform), e.Token, e.Reason, e.At.Unix(), e.Replacement}
+}
+
+type Feedback interface {
+	// Report queues invalid or replaced token event, repeated tokens within window are dropped
+	Report(project string, target task.Target, err error)
+	Close()
+}
+
+type writer interface {
+	Write(event *Event) error
+	Close() error
+}
+
+type noopFeedback struct{}
+
+func (noopFeedback) Report(project string, target task.Target, err error) {}
+
+func (noopFeedback) Close() {}
+
+func NewDefaultFeedback() Feedback {
+	var w writer
+	switch config.GetString("feedback.sink") {
+	case SinkTarantool:
+		w = newTntWriter()
+	case SinkWebhook:
+		w = newWebhookWriter()
+	case SinkFile:
+		var err error
+		if w, err = newFileWriter(config.GetString("feedback.file")); err != nil {
+			log.Errorf("feedback: cannot open file %s", err)
+			return noopFeedback{}
+		}
+	case "":
+		return noopFeedback{}
+	default:
+		log.Errorf("feedback: unknown sink %s, invalid tokens are not reported", config.GetString("feedback.sink"))
+		return noopFeedback{}
+	}
+
+	sp := spool.New[Event](config.GetString("feedback.spool_file"))
+	return newStream(w, config.GetDuration("feedback.dedup_window"), sp)
+}
+
+// stream writes events in background so slow consumer does not block workers,
+// events failed to write are spooled to local file and replayed later
+type stream struct {
+	events chan *Event
+	done   chan struct{}
+	writer writer
+	spool  *spool.Spool[Event]
+	window time.Duration
+	retry  time.Duration
+
+	mutex sync.Mutex
+	seen  map[string]time.Time
+}
+
+func newStream(w writer, window time.Duration, sp *spool.Spool[Event]) *stream {
+	s := &stream{
+		events: make(chan *Event, config.GetInt("feedback.buffer")),
+		done:   make(chan struct{}),
+		writer: w,
+		spool:  sp,
+		window: window,
+		retry:  config.GetDuration("feedback.retry_interval"),
+		seen:   make(map[string]time.Time),
+	}
+
+	if s.retry <= 0 {
+		s.retry = 10 * time.Second
+	}
+
+	go s.run()
+
+	return s
+}
+
+func key(project string, target task.Target) string {
+	return project + "\x00" + string(target.Type) + "\x00" + target.To
+}
+
+func (s *stream) Report(project string, target task.Target, err error) {
+	now := time.Now()
+	k := key(project, target)
+
+	// zero or negative window disables dedup
+	if s.window > 0 {
+		s.mutex.Lock()
+		if at, ok := s.seen[k]; ok && now.Sub(at) < s.window {
+			s.mutex.Unlock()
+			return
+		}
+		s.seen[k] = now
+		s.mutex.Unlock()
+	}
+
+	event := &Event{
+		Project:  project,
+		Platform: target.Type,
+		Token:    target.To,
+		Reason:   push.ErrorCode(err),
+		At:       now,
+	}
+	event.Replacement, _ = push.CanonicalTo(err)
+
+	select {
+	case s.events <- event:
+	default:
+		log.Errorf("feedback: buffer is full, drop %s %s", target.Type, target.To)
+		s.forget(k)
+	}
+}
+
+func (s *stream) forget(k string) {
+	s.mutex.Lock()
+	delete(s.seen, k)
+	s.mutex.Unlock()
+}
+
+func (s *stream) Close() {
+	close(s.events)
+	<-s.done
+}
+
+func (s *stream) run() {
+	defer close(s.done)
+
+	// seen tokens are expired only when dedup is on
+	var expire <-chan time.Time
+	if s.window > 0 {
+		ticker := time.NewTicker(s.window)
+		defer ticker.Stop()
+		expire = ticker.C
+	}
+
+	retry := time.NewTicker(s.retry)
+	defer retry.Stop()
+
+	for {
+		select {
+		case event, ok := <-s.events:
+			if !ok {
+				if s.spool.Exists() {
+					s.replay()
+				}
+				if err := s.writer.Close(); err != nil {
+					log.Errorf("feedback: close %s", err)
+				}
+				return
+			}
+			s.write(event)
+		case <-retry.C:
+			if s.spool.Exists() {
+				s.replay()
+			}
+		case <-expire:
+			s.expire()
+		}
+	}
+}
+
+// write replays spool first so events keep their order
+func (s *stream) write(event *Event) {
+	if s.spool.Exists() && !s.replay() {
+		s.toSpool(event)
+		return
+	}
+
+	if err := s.writer.Write(event); err != nil {
+		log.Errorf("feedback: cannot write %s %s %s", event.Platform, event.Token, err)
+		s.toSpool(event)
+	}
+}
+
+func (s *stream) replay() bool {
+	spooled, err := s.spool.Load()
+	if err != nil {
+		log.Errorf("feedback: cannot read spool %s", err)
+		return false
+	}
+
+	for i, event := range spooled {
+		if err := s.writer.Write(event); err != nil {
+			if i > 0 {
+				if err := s.spool.Replace(spooled[i:]); err != nil {
+					log.Errorf("feedback: cannot rewrite spool %s", err)
+				}
+			}
+			return false
+		}
+	}
+
+	if err := s.spool.Remove(); err != nil {
+		log.Errorf("feedback: cannot remove spool %s", err)
+		return false
+	}
+
+	log.Infof("feedback: replayed %d spooled events", len(spooled))
+	return true
+}
+
+func (s *stream) toSpool(event *Event) {
+	if err := s.spool.Append([]*Event{event}); err != nil {
+		log.Errorf("feedback: cannot spool %s %s, lost %s", event.Platform, event.Token, err)
+		// next report of the token is written again
+		s.forget(key(event.Project, task.Target{Type: event.Platform, To: event.Token}))
+	}
+}
+
+func (s *stream) expire() {
+	now := time.Now()
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for k, at := range s.seen {
+		if now.Sub(at) >= s.window {
+			delete(s.seen, k)
+		}
+	}
+}
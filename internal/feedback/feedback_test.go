@@ -0,0 +1,148 @@
+package feedback
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"push-sender/internal/push"
+	"push-sender/internal/spool"
+	"push-sender/internal/task"
+)
+
+func TestStreamDedup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.jsonl")
+
+	w, err := newFileWriter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := newStream(w, time.Hour, spool.New[Event](filepath.Join(t.TempDir(), "feedback.spool")))
+	target := task.Target{Type: task.Android, To: "tok"}
+	s.Report("p", target, push.ErrorTokenRemoved)
+	s.Report("p", target, push.ErrorTokenRemoved)
+	s.Report("p2", target, push.ErrorTokenRemoved)
+	s.Report("p", task.Target{Type: task.Ios, To: "tok"}, push.ErrorTokenRemoved)
+	s.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var events []Event
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events got %v", events)
+	}
+	if events[0].Project != "p" || events[0].Platform != task.Android || events[0].Token != "tok" ||
+		events[0].Reason != "TokenRemoved.STATUS_URL.ACTION_REMOVE" {
+		t.Fatalf("bad event %#v", events[0])
+	}
+}
+
+func TestStreamWindow(t *testing.T) {
+	w := &memWriter{}
+	s := newStream(w, 20*time.Millisecond, spool.New[Event](filepath.Join(t.TempDir(), "feedback.spool")))
+	target := task.Target{Type: task.Android, To: "tok"}
+	s.Report("p", target, push.ErrorTokenRemoved)
+	time.Sleep(30 * time.Millisecond)
+	s.Report("p", target, push.ErrorTokenRemoved)
+	s.Close()
+
+	if len(w.events) != 2 {
+		t.Fatalf("expected report after window %v", w.events)
+	}
+}
+
+func TestStreamNoDedup(t *testing.T) {
+	w := &memWriter{}
+	s := newStream(w, 0, spool.New[Event](filepath.Join(t.TempDir(), "feedback.spool")))
+	target := task.Target{Type: task.Android, To: "tok"}
+	s.Report("p", target, push.ErrorTokenRemoved)
+	s.Report("p", target, push.ErrorTokenRemoved)
+	s.Close()
+
+	if len(w.events) != 2 {
+		t.Fatalf("expected every report without window %v", w.events)
+	}
+}
+
+func TestStreamCanonical(t *testing.T) {
+	w := &memWriter{}
+	s := newStream(w, time.Hour, spool.New[Event](filepath.Join(t.TempDir(), "feedback.spool")))
+	s.Report("p", task.Target{Type: task.Amazon, To: "old"}, push.NewCanonicalError("new"))
+	s.Close()
+
+	if len(w.events) != 1 || w.events[0].Reason != "Canonical" || w.events[0].Replacement != "new" {
+		t.Fatalf("bad canonical event %#v", w.events)
+	}
+	if tuple := w.events[0].Tuple(); len(tuple) != 6 || tuple[5] != "new" {
+		t.Fatalf("bad tuple %v", tuple)
+	}
+}
+
+func TestStreamSpool(t *testing.T) {
+	sp := spool.New[Event](filepath.Join(t.TempDir(), "feedback.spool"))
+
+	w := &memWriter{down: true}
+	s := newStream(w, time.Hour, sp)
+	s.Report("p", task.Target{Type: task.Android, To: "tok1"}, push.ErrorTokenRemoved)
+	s.Report("p", task.Target{Type: task.Android, To: "tok2"}, push.ErrorTokenRemoved)
+	s.Close()
+
+	if len(w.events) != 0 || !sp.Exists() {
+		t.Fatalf("events not spooled")
+	}
+
+	w.down = false
+
+	s = newStream(w, time.Hour, sp)
+	s.Report("p", task.Target{Type: task.Android, To: "tok3"}, push.ErrorTokenRemoved)
+	s.Close()
+
+	if len(w.events) != 3 {
+		t.Fatalf("bad written %v", w.events)
+	}
+	for i, e := range w.events {
+		if e.Token != fmt.Sprintf("tok%d", i+1) {
+			t.Fatalf("order broken %v", w.events)
+		}
+	}
+	if sp.Exists() {
+		t.Fatalf("spool not removed")
+	}
+}
+
+type memWriter struct {
+	events []*Event
+	down   bool
+}
+
+func (w *memWriter) Write(event *Event) error {
+	if w.down {
+		return errors.New("down")
+	}
+	w.events = append(w.events, event)
+	return nil
+}
+
+func (w *memWriter) Close() error {
+	return nil
+}
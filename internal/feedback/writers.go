@@ -0,0 +1,97 @@
+package feedback
+
+import (
+	"os"
+
+	"github.com/goccy/go-json"
+	"github.com/tarantool/go-tarantool/v2/pool"
+	"github.com/tarantool/go-tarantool/v2/queue"
+
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push/webhook"
+	"push-sender/internal/tnt"
+)
+
+func init() {
+	config.SetDefault("feedback.queue", "push_feedback")
+	config.SetDefault("feedback.file", "feedback.jsonl")
+}
+
+// tntWriter puts events to tarantool queue
+type tntWriter struct {
+	queue string
+	pool  *tnt.LazyPool
+}
+
+func newTntWriter() *tntWriter {
+	return &tntWriter{
+		queue: config.GetString("feedback.queue"),
+		pool:  tnt.DefaultPool(),
+	}
+}
+
+func (w *tntWriter) Write(event *Event) error {
+	connPool, err := w.pool.Get()
+	if err != nil {
+		return err
+	}
+	_, err = queue.New(pool.NewConnectorAdapter(connPool, pool.RW), w.queue).Put(event.Tuple())
+	return err
+}
+
+func (w *tntWriter) Close() error {
+	return nil
+}
+
+// webhookWriter posts signed json events like webhook transport does
+type webhookWriter struct {
+	url  string
+	opts *webhook.WebhookOpts
+}
+
+func newWebhookWriter() *webhookWriter {
+	return &webhookWriter{
+		url: config.GetString("feedback.webhook_url"),
+		opts: &webhook.WebhookOpts{
+			Secret:       config.GetString("feedback.webhook_secret"),
+			Timeout:      config.GetDuration("webhook_timeout"),
+			SuccessCodes: config.GetIntSlice("webhook_success_codes"),
+		},
+	}
+}
+
+func (w *webhookWriter) Write(event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	w.opts.ProjectId = event.Project
+	return webhook.Send(w.url, string(data), w.opts)
+}
+
+func (w *webhookWriter) Close() error {
+	return nil
+}
+
+// fileWriter appends json lines
+type fileWriter struct {
+	file *os.File
+	enc  *json.Encoder
+}
+
+func newFileWriter(path string) (*fileWriter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileWriter{file: f, enc: json.NewEncoder(f)}, nil
+}
+
+func (w *fileWriter) Write(event *Event) error {
+	return w.enc.Encode(event)
+}
+
+func (w *fileWriter) Close() error {
+	return w.file.Close()
+}
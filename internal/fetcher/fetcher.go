@@ -3,22 +3,63 @@ package fetcher
 import (
 	"context"
 	"errors"
+	"fmt"
 	"push-sender/internal/task"
+	"time"
+
+	config "github.com/spf13/viper"
 )
 
 var ErrContinue = errors.New("Continue")
 
+// MalformedError is returned for task which cannot be parsed, it is already
+// removed from source and Task has only its id, lane and project
+type MalformedError struct {
+	Task *task.Task
+	Err  error
+}
+
+func (e *MalformedError) Error() string {
+	return fmt.Sprintf("malformed task %d %s", e.Task.ID, e.Err)
+}
+
+func (e *MalformedError) Unwrap() error {
+	return e.Err
+}
+
+func init() {
+	// default or tarantool
+	config.SetDefault("fetcher", "default")
+}
+
 type Fetcher interface {
 	Get() (*task.Task, error)
+	// Ack removes processed task from source
+	Ack(qtask *task.Task) error
+	// Release returns task to source to be taken again after delay
+	Release(qtask *task.Task, delay time.Duration) error
 }
 
 type defaultFetcher struct {
 }
 
-func NewDefaultFetcher(_ context.Context) Fetcher {
+func NewDefaultFetcher(ctx context.Context) Fetcher {
+	if config.GetString("fetcher") == "tarantool" {
+		return NewTntFetcher(ctx)
+	}
 	return &defaultFetcher{}
 }
 
+// Get emulates empty queue
 func (f *defaultFetcher) Get() (*task.Task, error) {
-	return &task.Task{}, nil
+	time.Sleep(time.Second)
+	return nil, ErrContinue
+}
+
+func (f *defaultFetcher) Ack(qtask *task.Task) error {
+	return nil
+}
+
+func (f *defaultFetcher) Release(qtask *task.Task, delay time.Duration) error {
+	return nil
 }
@@ -2,53 +2,176 @@ package fetcher
 
 import (
 	"context"
+	"errors"
+	"push-sender/internal/lanes"
+	"push-sender/internal/push"
 	"push-sender/internal/task"
+	"sync"
 	"time"
 
 	"push-sender/internal/tnt"
 
 	log "github.com/sirupsen/logrus"
-	config "github.com/spf13/viper"
 	"github.com/tarantool/go-tarantool/v2/pool"
+	"github.com/tarantool/go-tarantool/v2/queue"
 )
 
-type tntFetcher struct {
+// tuple field with optional fallback chain
+const fallbackField = 4
+
+var ErrNotTaken = errors.New("task is not taken")
+
+// takenTask is implemented by queue.Task
+type takenTask interface {
+	Id() uint64
+	Data() interface{}
+	Ack() error
+	Bury() error
+	ReleaseCfg(cfg queue.Opts) error
+}
+
+type tntLane struct {
+	*lanes.Lane
 	queue tnt.Queue
 }
 
+// task ids are unique within one queue only
+type takenKey struct {
+	lane string
+	id   uint64
+}
+
+type tntFetcher struct {
+	lanes     []*tntLane
+	scheduler *lanes.Scheduler
+
+	// tasks taken and not yet acked or released
+	mutex sync.Mutex
+	taken map[takenKey]takenTask
+}
+
 func NewTntFetcher(ctx context.Context) Fetcher {
-	qu, err := tnt.NewTntQueue(ctx,
-		config.GetString("tarantool.queue_name"),
-		&tnt.TntCfg{
-			Addrs:    config.GetStringSlice("tarantool.queue"),
-			User:     config.GetString("tarantool.user"),
-			Password: config.GetString("tarantool.password"),
-			Timeout:  config.GetDuration("tarantool.timeout"),
-		})
+	configured := lanes.Configured()
 
-	if err != nil {
-		log.Fatal("cannot connection to tarantool queue")
-		return nil
+	f := &tntFetcher{
+		lanes:     make([]*tntLane, 0, len(configured)),
+		scheduler: lanes.NewScheduler(configured),
+		taken:     make(map[takenKey]takenTask),
 	}
 
-	return &tntFetcher{
-		queue: qu,
+	for _, l := range configured {
+		qu, err := tnt.NewTntQueue(ctx, l.Queue, tnt.NewDefaultCfg())
+
+		if err != nil {
+			log.Fatalf("cannot connection to tarantool queue %s", l.Queue)
+			return nil
+		}
+
+		f.lanes = append(f.lanes, &tntLane{Lane: l, queue: qu})
 	}
+
+	return f
 }
 
+// Get takes task from lane chosen by weight, other lanes are tried when it is empty
 func (f *tntFetcher) Get() (*task.Task, error) {
-	qtask, err := f.queue.TakeTimeout(1 * time.Second)
+	order := f.scheduler.Order()
+
+	for _, i := range order {
+		l := f.lanes[i]
+		qtask, err := l.queue.TakeTimeout(0)
+		if err == nil && qtask != nil {
+			return f.parse(l, qtask)
+		}
+	}
+
+	// all lanes are empty, wait on the scheduled one
+	l := f.lanes[order[0]]
+	qtask, err := l.queue.TakeTimeout(100 * time.Millisecond)
 	if err == pool.ErrNoRwInstance || qtask == nil {
 		return nil, ErrContinue
 	}
 
-	ret_task := &task.Task{
-		ID: qtask.Id(),
+	return f.parse(l, qtask)
+}
+
+func (f *tntFetcher) parse(l *tntLane, qtask takenTask) (*task.Task, error) {
+	lanes.Metrics.Add(l.Name+".taken", 1)
+
+	ret_task, err := parseTuple(qtask.Data())
+	if err != nil {
+		// malformed tuple cannot be sent, it is buried to stay for inspection and not be taken again
+		if e := qtask.Bury(); e != nil {
+			log.Errorf("cannot bury malformed task %d of lane %s %s", qtask.Id(), l.Name, e)
+		}
+		lanes.Metrics.Add(l.Name+".buried", 1)
+
+		malformed := &task.Task{ID: qtask.Id(), Lane: l.Name}
+		if fields, ok := qtask.Data().([]any); ok && len(fields) > 0 {
+			malformed.Project, _ = fields[0].(string)
+		}
+		return nil, &MalformedError{Task: malformed, Err: err}
 	}
+	ret_task.ID = qtask.Id()
+	ret_task.Lane = l.Name
+
+	f.mutex.Lock()
+	f.taken[takenKey{lane: l.Name, id: ret_task.ID}] = qtask
+	f.mutex.Unlock()
+
+	return ret_task, nil
+}
+
+// parseTuple converts queue task data to task
+func parseTuple(data any) (*task.Task, error) {
+	ret_task := &task.Task{}
 
-	if err := tnt.ScanFieldsAnyToStruct(qtask.Data(), ret_task); err != nil {
+	if err := tnt.ScanFieldsAnyToStruct(data, ret_task); err != nil {
 		return nil, err
 	}
 
+	if fields, ok := data.([]any); ok && len(fields) > fallbackField {
+		ret_task.Fallback = task.ParseTargets(fields[fallbackField])
+	}
+
+	// raw android and adm data comes as msgpack map, transports take map[string]string
+	if _, unified := push.ParseMessage(ret_task.Payload); !unified {
+		switch ret_task.Payload.(type) {
+		case map[any]any, map[string]any:
+			ret_task.Payload, _ = tnt.MapToMapStrings(ret_task.Payload)
+		}
+	}
+
 	return ret_task, nil
 }
+
+func (f *tntFetcher) pop(qtask *task.Task) (takenTask, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	key := takenKey{lane: qtask.Lane, id: qtask.ID}
+	t, ok := f.taken[key]
+	if !ok {
+		return nil, ErrNotTaken
+	}
+	delete(f.taken, key)
+	return t, nil
+}
+
+func (f *tntFetcher) Ack(qtask *task.Task) error {
+	t, err := f.pop(qtask)
+	if err != nil {
+		return err
+	}
+	lanes.Metrics.Add(qtask.Lane+".acked", 1)
+	return t.Ack()
+}
+
+func (f *tntFetcher) Release(qtask *task.Task, delay time.Duration) error {
+	t, err := f.pop(qtask)
+	if err != nil {
+		return err
+	}
+	lanes.Metrics.Add(qtask.Lane+".released", 1)
+	return t.ReleaseCfg(queue.Opts{Delay: delay})
+}
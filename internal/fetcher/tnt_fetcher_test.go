@@ -0,0 +1,135 @@
+package fetcher
+
+import (
+	"errors"
+	"push-sender/internal/lanes"
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+	"push-sender/internal/validate"
+	"reflect"
+	"testing"
+
+	"github.com/tarantool/go-tarantool/v2/queue"
+)
+
+// tuples are given as msgpack decoder returns them: compact ints, untyped maps
+func TestParseTuple(t *testing.T) {
+	tuple := []any{
+		"com.example.app",
+		"android",
+		"token",
+		map[any]any{"title": "hi"},
+		[]any{[]any{"huawei", "hms-token"}, map[any]any{"type": "ios", "to": "apns-token"}},
+		"order_ready",
+		"ru-RU",
+		map[any]any{"order": int8(42), "address": "Lenina 1"},
+		uint32(1700000000),
+		"Europe/Moscow",
+		"22:00-08:00",
+		int64(1699990000),
+		uint32(1700003600),
+	}
+
+	qtask, err := parseTuple(tuple)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &task.Task{
+		Project: "com.example.app",
+		Type:    task.Android,
+		To:      "token",
+		Payload: map[string]string{"title": "hi"},
+		Fallback: []task.Target{
+			{Type: task.Huawei, To: "hms-token"},
+			{Type: task.Ios, To: "apns-token"},
+		},
+		Template:   "order_ready",
+		Locale:     "ru-RU",
+		Variables:  map[string]string{"order": "42", "address": "Lenina 1"},
+		SendAt:     1700000000,
+		Timezone:   "Europe/Moscow",
+		QuietHours: "22:00-08:00",
+		EnqueuedAt: 1699990000,
+		ExpiresAt:  1700003600,
+	}
+	if !reflect.DeepEqual(qtask, want) {
+		t.Fatalf("got %#v\nwant %#v", qtask, want)
+	}
+}
+
+func TestParseTupleOptional(t *testing.T) {
+	qtask, err := parseTuple([]any{"project", "ios", "token", `{"aps":{}}`, nil, nil, "en"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if qtask.Type != task.Ios || qtask.Payload != `{"aps":{}}` || qtask.Fallback != nil || qtask.Template != "" || qtask.Locale != "en" {
+		t.Fatalf("bad task %#v", qtask)
+	}
+
+	if _, err := parseTuple([]any{"project", "ios", "token"}); err == nil {
+		t.Error("tuple without required fields parsed")
+	}
+	if _, err := parseTuple([]any{"project", nil, "token", "{}"}); err == nil {
+		t.Error("tuple with empty required field parsed")
+	}
+}
+
+// raw data maps of android and adm must pass validation as transports take them
+func TestParseTupleRawMap(t *testing.T) {
+	for _, platform := range []string{"android", "amazon"} {
+		qtask, err := parseTuple([]any{"project", platform, "token", map[any]any{"order": int8(42), "kind": "ready"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{"order": "42", "kind": "ready"}
+		if !reflect.DeepEqual(qtask.Payload, want) {
+			t.Fatalf("%s: bad payload %#v", platform, qtask.Payload)
+		}
+		if err := validate.Task(qtask); err != nil {
+			t.Errorf("%s: %s", platform, err)
+		}
+	}
+
+	qtask, err := parseTuple([]any{"project", "amazon", "token", map[any]any{push.MessageKey: map[any]any{"title": "hi"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg, ok := push.ParseMessage(qtask.Payload); !ok || msg.Title != "hi" {
+		t.Fatalf("unified message is not kept %#v", qtask.Payload)
+	}
+	if err := validate.Task(qtask); err != nil {
+		t.Error(err)
+	}
+}
+
+type fakeTaken struct {
+	id     uint64
+	data   any
+	buried bool
+}
+
+func (f *fakeTaken) Id() uint64                      { return f.id }
+func (f *fakeTaken) Data() interface{}               { return f.data }
+func (f *fakeTaken) Ack() error                      { return nil }
+func (f *fakeTaken) Bury() error                     { f.buried = true; return nil }
+func (f *fakeTaken) ReleaseCfg(cfg queue.Opts) error { return nil }
+
+func TestParseMalformed(t *testing.T) {
+	f := &tntFetcher{taken: make(map[takenKey]takenTask)}
+	l := &tntLane{Lane: &lanes.Lane{Name: "bulk"}}
+
+	taken := &fakeTaken{id: 7, data: []any{"project", nil, "token", "{}"}}
+	_, err := f.parse(l, taken)
+
+	var malformed *MalformedError
+	if !errors.As(err, &malformed) {
+		t.Fatalf("expected malformed error got %v", err)
+	}
+	if malformed.Task.ID != 7 || malformed.Task.Lane != "bulk" || malformed.Task.Project != "project" {
+		t.Errorf("bad malformed task %#v", malformed.Task)
+	}
+	if !taken.buried || len(f.taken) != 0 {
+		t.Errorf("malformed task is not buried %v or kept taken %v", taken.buried, f.taken)
+	}
+}
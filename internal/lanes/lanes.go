@@ -0,0 +1,116 @@
+package lanes
+
+/**
+ *	Priority lanes: every lane is a tarantool queue consumed with weight,
+ *	tasks of high lanes are preferred by workers and served by reserved ones
+ *
+ *	lanes:
+ *	  transactional:
+ *	    queue: push_high
+ *	    weight: 8
+ *	    high: true
+ *	  marketing:
+ *	    queue: push_bulk
+ *	    weight: 1
+ *
+ *	Without lanes section single default lane reads tarantool.queue_name.
+ */
+
+import (
+	"expvar"
+	"sort"
+
+	config "github.com/spf13/viper"
+)
+
+const DefaultLane = "default"
+
+type Lane struct {
+	Name   string
+	Queue  string
+	Weight int
+	High   bool
+}
+
+// Metrics counts "<lane>.taken", "<lane>.acked" and "<lane>.released", served at /debug/vars
+var Metrics = expvar.NewMap("lanes")
+
+// Configured returns lanes sorted by weight descending
+func Configured() []*Lane {
+	names := config.GetStringMap("lanes")
+	if len(names) == 0 {
+		return []*Lane{{
+			Name:   DefaultLane,
+			Queue:  config.GetString("tarantool.queue_name"),
+			Weight: 1,
+		}}
+	}
+
+	list := make([]*Lane, 0, len(names))
+	for name := range names {
+		prefix := "lanes." + name + "."
+		l := &Lane{
+			Name:   name,
+			Queue:  config.GetString(prefix + "queue"),
+			Weight: config.GetInt(prefix + "weight"),
+			High:   config.GetBool(prefix + "high"),
+		}
+		if l.Weight <= 0 {
+			l.Weight = 1
+		}
+		list = append(list, l)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Weight != list[j].Weight {
+			return list[i].Weight > list[j].Weight
+		}
+		return list[i].Name < list[j].Name
+	})
+
+	return list
+}
+
+// IsHigh reports tasks of lane are high priority
+func IsHigh(name string) bool {
+	return config.GetBool("lanes." + name + ".high")
+}
+
+// Scheduler is smooth weighted round robin over lanes
+type Scheduler struct {
+	lanes   []*Lane
+	current []int
+	total   int
+}
+
+func NewScheduler(lanes []*Lane) *Scheduler {
+	s := &Scheduler{
+		lanes:   lanes,
+		current: make([]int, len(lanes)),
+	}
+	for _, l := range lanes {
+		s.total += l.Weight
+	}
+	return s
+}
+
+// Order returns lane indexes to try: the scheduled lane first, then the rest by weight
+func (s *Scheduler) Order() []int {
+	best := 0
+	for i, l := range s.lanes {
+		s.current[i] += l.Weight
+		if s.current[i] > s.current[best] {
+			best = i
+		}
+	}
+	s.current[best] -= s.total
+
+	order := make([]int, 0, len(s.lanes))
+	order = append(order, best)
+	for i := range s.lanes {
+		if i != best {
+			order = append(order, i)
+		}
+	}
+	return order
+}
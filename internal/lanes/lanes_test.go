@@ -0,0 +1,42 @@
+package lanes
+
+import (
+	"testing"
+
+	config "github.com/spf13/viper"
+)
+
+func TestConfigured(t *testing.T) {
+	config.Set("tarantool.queue_name", "push")
+	if list := Configured(); len(list) != 1 || list[0].Queue != "push" || list[0].Name != DefaultLane {
+		t.Fatalf("bad default lane %v", list)
+	}
+
+	config.Set("lanes", map[string]any{
+		"bulk": map[string]any{"queue": "push_bulk"},
+		"otp":  map[string]any{"queue": "push_otp", "weight": 8, "high": true},
+	})
+	defer config.Set("lanes", nil)
+
+	list := Configured()
+	if len(list) != 2 || list[0].Name != "otp" || list[1].Weight != 1 || !IsHigh("otp") || IsHigh("bulk") {
+		t.Fatalf("bad lanes %v", list)
+	}
+}
+
+func TestScheduler(t *testing.T) {
+	s := NewScheduler([]*Lane{{Name: "a", Weight: 3}, {Name: "b", Weight: 1}})
+
+	picks := map[int]int{}
+	for i := 0; i < 8; i++ {
+		order := s.Order()
+		if len(order) != 2 || order[0] == order[1] {
+			t.Fatalf("bad order %v", order)
+		}
+		picks[order[0]]++
+	}
+
+	if picks[0] != 6 || picks[1] != 2 {
+		t.Fatalf("bad weights %v", picks)
+	}
+}
@@ -17,14 +17,18 @@ type defaultLiveness struct {
 }
 
 func (dl defaultLiveness) Start(ctx context.Context, wg *sync.WaitGroup) {
+	// own mux, so handlers registered on default one like expvar are not public
+	mux := http.NewServeMux()
+
 	// for init liveness and readness k8s docker probe
-	http.HandleFunc("/ping/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/ping/", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
 		w.Write([]byte("ok"))
 	})
 
 	srv := http.Server{
-		Addr: config.GetString("liveness_port"),
+		Addr:    config.GetString("liveness_port"),
+		Handler: mux,
 	}
 
 	wg.Add(2)
@@ -0,0 +1,210 @@
+package adm
+
+/**
+ *	Implemented amazon device messaging HTTP Proto
+ */
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+var transport *http.Transport
+
+const (
+	invalidRegistrationId = "InvalidRegistrationId"
+	unregistered          = "Unregistered"
+	accessTokenExpired    = "AccessTokenExpired"
+	messageTooLarge       = "MessageTooLarge"
+	maxRateExceeded       = "MaxRateExceeded"
+	// data size limit
+	MAX_SIZE = 6144
+)
+
+func init() {
+	config.SetDefault("adm_send_api", "https://api.amazon.com/messaging/registrations/%s/messages")
+	config.SetDefault("adm_oauth_api", "https://api.amazon.com/auth/o2/token")
+	transport = &http.Transport{
+		MaxIdleConnsPerHost: 10,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     1 * time.Second,
+		TLSClientConfig:     &tls.Config{},
+	}
+}
+
+type AdmMessage struct {
+	Data map[string]string `json:"data"`
+	// newer message with the same key replaces undelivered older one
+	ConsolidationKey string `json:"consolidationKey,omitempty"`
+	// seconds adm keeps message for offline device
+	ExpiresAfter int `json:"expiresAfter,omitempty"`
+}
+
+type AdmMessageOpts struct {
+	ClientId     string
+	ClientSecret string
+	// default expiresAfter, seconds
+	TimeToLive int
+}
+
+type AdmResponse struct {
+	RegistrationId string `json:"registrationID,omitempty"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+func (opts *AdmMessageOpts) Valid() bool {
+	return opts.ClientId != "" && opts.ClientSecret != ""
+}
+
+// Send returns registration id adm replied with, it differs from to when
+// adm asks to use canonical one from now on
+func (sender *AdmSender) Send(to string, msg *AdmMessage, opts *AdmMessageOpts) (string, error) {
+	if opts == nil || !opts.Valid() || msg == nil || to == "" {
+		log.Errorf("newpusher adm: opts not valid [%#v] [%s]", opts, to)
+		return "", push.ErrorRequest
+	}
+
+	if msg.ExpiresAfter == 0 {
+		msg.ExpiresAfter = opts.TimeToLive
+	}
+
+	j, err := json.Marshal(msg)
+
+	if err != nil {
+		log.Errorf("newpusher adm: cannot j, err := json.Marshal(&msg) %s", err)
+		return "", push.ErrorRequest
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := sender.GetAuthToken(opts)
+
+		if err != nil {
+			log.Errorf("newpusher adm: get auth token %s", err)
+			// rejected credentials are permanent, oauth outage is retried
+			return "", err
+		}
+
+		request, err := http.NewRequest(http.MethodPost, fmt.Sprintf(config.GetString("adm_send_api"), url.PathEscape(to)), bytes.NewBuffer(j))
+
+		if err != nil {
+			log.Errorf("newpusher adm: cannot read options %s", err)
+			return "", push.ErrorRequest
+		}
+		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		request.Header.Add("Content-Type", "application/json")
+		request.Header.Add("Accept", "application/json")
+		request.Header.Add("X-Amzn-Type-Version", "com.amazon.device.messaging.ADMMessage@1.0")
+		request.Header.Add("X-Amzn-Accept-Type", "com.amazon.device.messaging.ADMSendResult@1.0")
+
+		client := http.Client{Transport: transport}
+
+		resp, err := client.Do(request)
+
+		defer func() {
+			if resp != nil && resp.Body != nil {
+				resp.Body.Close()
+			}
+		}()
+
+		if err != nil {
+			log.Errorf("newpusher adm: cannot send request %s", err)
+			return "", push.ErrorTransportProblem
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+
+		// throttling is returned as RetryError, task is released instead of holding worker
+		registrationId, err := parseReply(resp, body)
+
+		if errors.Is(err, push.ErrorRefreshToken) {
+			delete(sender.Tokens, opts.ClientId)
+			continue
+		}
+
+		if registrationId != "" && registrationId != to {
+			log.Infof("newpusher adm: canonical registration id %s for %s", registrationId, to)
+		}
+
+		return registrationId, err
+	}
+
+	return "", push.ErrorServiceUnavailable
+}
+
+// parseReply returns registration id of accepted message
+func parseReply(resp *http.Response, body []byte) (string, error) {
+	var reply AdmResponse
+
+	if err := json.Unmarshal(body, &reply); err != nil {
+		log.Errorf("newpusher adm: parse reply error %s %s %s", resp.Status, err, string(body))
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return reply.RegistrationId, nil
+	}
+
+	log.Errorf("newpusher adm: bad reply %s %#v", resp.Status, reply)
+
+	switch reply.Reason {
+	case messageTooLarge:
+		return "", push.ErrorRequest
+	case maxRateExceeded:
+		return "", withRetryAfter(resp, push.ErrorRateLimit)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest:
+		switch reply.Reason {
+		case invalidRegistrationId, unregistered:
+			return "", push.ErrorTokenRemoved
+		}
+		return "", push.ErrorRequest
+	case http.StatusUnauthorized:
+		if reply.Reason == accessTokenExpired {
+			return "", push.ErrorRefreshToken
+		}
+		return "", push.ErrorInvalidKey
+	case http.StatusForbidden:
+		return "", push.ErrorPerissionDenied
+	case http.StatusRequestEntityTooLarge:
+		return "", push.ErrorRequest
+	case http.StatusTooManyRequests:
+		return "", withRetryAfter(resp, push.ErrorRateLimit)
+	case http.StatusServiceUnavailable:
+		return "", withRetryAfter(resp, push.ErrorServiceUnavailable)
+	}
+
+	return "", push.ErrorServiceUnavailable
+}
+
+// withRetryAfter wraps err to RetryError when adm sets Retry-After header
+func withRetryAfter(resp *http.Response, err error) error {
+	if after, ok := push.ParseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		return push.NewRetryError(err, after)
+	}
+	return err
+}
+
+/**
+https://developer.amazon.com/docs/adm/send-message.html
+
+200 Success, body contains registrationID, may be the canonical one.
+400 InvalidRegistrationId, InvalidData, InvalidConsolidationKey, InvalidExpiration, InvalidChecksum, InvalidType, Unregistered
+401 AccessTokenExpired
+413 MessageTooLarge
+429 MaxRateExceeded, Retry-After header is set
+500 Internal server error
+503 Server temporarily unavailable, Retry-After header may be set
+*/
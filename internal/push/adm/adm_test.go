@@ -0,0 +1,88 @@
+package adm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
+)
+
+func TestAdm(t *testing.T) {
+	status := http.StatusOK
+	reply := `{"registrationID":"amzn1.adm-registration.v3.xxx"}`
+	sends := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/o2/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("scope") != "messaging:push" {
+			t.Errorf("bad scope %v", r.PostForm)
+		}
+		fmt.Fprint(w, `{"access_token":"token","expires_in":3600}`)
+	})
+	mux.HandleFunc("/messaging/registrations/xxxx/messages", func(w http.ResponseWriter, r *http.Request) {
+		sends++
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "30")
+		}
+		w.WriteHeader(status)
+		fmt.Fprint(w, reply)
+		status, reply = http.StatusOK, `{}`
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	config.Set("adm_oauth_api", srv.URL+"/auth/o2/token")
+	config.Set("adm_send_api", srv.URL+"/messaging/registrations/%s/messages")
+
+	opts := &AdmMessageOpts{
+		ClientId:     "client",
+		ClientSecret: "secret",
+	}
+
+	sender := New()
+
+	msg := &AdmMessage{Data: map[string]string{"message": "hello"}, ConsolidationKey: "chat"}
+
+	if id, err := sender.Send("xxxx", msg, opts); err != nil || id != "amzn1.adm-registration.v3.xxx" {
+		t.Errorf("expected canonical registration id, got %q %v", id, err)
+	}
+
+	status, reply = http.StatusTooManyRequests, `{"reason":"MaxRateExceeded"}`
+	sends = 0
+	_, err := sender.Send("xxxx", msg, opts)
+	if after, ok := push.RetryAfter(err); !ok || after != 30*time.Second || !errors.Is(err, push.ErrorRateLimit) || sends != 1 {
+		t.Errorf("expected rate limit retry error, got %s %d", err, sends)
+	}
+
+	status, reply = http.StatusRequestEntityTooLarge, `{"reason":"MessageTooLarge"}`
+	if _, err := sender.Send("xxxx", msg, opts); !errors.Is(err, push.ErrorRequest) {
+		t.Errorf("expected bad request, got %s", err)
+	}
+
+	status, reply = http.StatusBadRequest, `{"reason":"Unregistered"}`
+	if _, err := sender.Send("xxxx", msg, opts); !errors.Is(err, push.ErrorTokenRemoved) {
+		t.Errorf("expected token removed, got %s", err)
+	}
+}
+
+func TestAdmOAuthOutage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	config.Set("adm_oauth_api", srv.URL+"/auth/o2/token")
+
+	opts := &AdmMessageOpts{ClientId: "client", ClientSecret: "secret"}
+	if _, err := New().Send("xxxx", &AdmMessage{Data: map[string]string{"k": "v"}}, opts); err == nil || push.IsPermanent(err) {
+		t.Errorf("expected temporary error on oauth outage, got %v", err)
+	}
+}
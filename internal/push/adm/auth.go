@@ -0,0 +1,102 @@
+package adm
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+// login with amazon token response
+type LwaTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	Scope            string `json:"scope,omitempty"`
+	TokenType        string `json:"token_type,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type AdmToken struct {
+	AccessToken string
+	Expired     time.Time
+}
+
+func (sender *AdmSender) RefreshToken(opts *AdmMessageOpts) (string, error) {
+	postData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"scope":         {"messaging:push"},
+		"client_id":     {opts.ClientId},
+		"client_secret": {opts.ClientSecret},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, config.GetString("adm_oauth_api"), bytes.NewBufferString(postData.Encode()))
+
+	if err != nil {
+		log.Errorf("newpusher adm: cannot make request %s", err)
+		return "", push.ErrorRequest
+	}
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+
+	client := http.Client{Transport: transport}
+
+	resp, err := client.Do(request)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("newpusher adm: cannot send request %s", err)
+		return "", push.ErrorTransportProblem
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != 200 {
+		log.Errorf("newpusher adm: cannot status request %s [%s]", resp.Status, string(body))
+		return "", push.OAuthStatusError(resp.StatusCode)
+	}
+
+	var token LwaTokenResponse
+
+	err = json.Unmarshal(body, &token)
+
+	if err != nil || token.AccessToken == "" {
+		log.Errorf("newpusher adm: cannot unmarshal resp request %s", body)
+		return "", push.ErrorServiceUnavailable
+	}
+
+	sender.Tokens[opts.ClientId] = &AdmToken{
+		AccessToken: token.AccessToken,
+		Expired:     time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+
+	return token.AccessToken, nil
+}
+
+type AdmSender struct {
+	Tokens map[string]*AdmToken
+}
+
+func New() *AdmSender {
+	return &AdmSender{
+		Tokens: make(map[string]*AdmToken),
+	}
+}
+
+func (sender *AdmSender) GetAuthToken(opts *AdmMessageOpts) (string, error) {
+	if token, ok := sender.Tokens[opts.ClientId]; ok && time.Until(token.Expired) > time.Minute {
+		return token.AccessToken, nil
+	}
+	return sender.RefreshToken(opts)
+}
@@ -0,0 +1,27 @@
+package adm
+
+import (
+	"push-sender/internal/push"
+)
+
+// RenderMessage translates unified message to adm data message, adm has no
+// notification so title, body and image are passed as data keys
+func RenderMessage(msg *push.Message) *AdmMessage {
+	data := make(map[string]string, len(msg.Data)+3)
+	for k, v := range msg.Data {
+		data[k] = v
+	}
+
+	fields := map[string]string{"title": msg.Title, "body": msg.Body, "image": msg.Image}
+	for k, v := range fields {
+		if v != "" {
+			data[k] = v
+		}
+	}
+
+	return &AdmMessage{
+		Data:             data,
+		ConsolidationKey: msg.CollapseKey,
+		ExpiresAfter:     msg.TTL,
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/goccy/go-json"
@@ -35,8 +36,19 @@ const (
 	unregistered        = "UNREGISTERED"
 	unavailable         = "UNAVAILABLE"
 	unauth              = "UNAUTHENTICATED"
+	// data keys and values size limit
+	MAX_SIZE = 4096
 )
 
+// IsReservedKey reports data key is used by fcm itself and rejected in payload
+func IsReservedKey(key string) bool {
+	switch key {
+	case "from", "notification", "message_type":
+		return true
+	}
+	return strings.HasPrefix(key, "google.") || strings.HasPrefix(key, "gcm.")
+}
+
 var transport *http.Transport
 
 func init() {
@@ -89,31 +101,32 @@ func (opts *FcmMessageOpts) Valid() bool {
 	return opts.Cfg != nil
 }
 
-func parseReply(body []byte) error {
+// parseReply returns fcm message name of accepted message
+func parseReply(body []byte) (string, error) {
 	var resp FcmResponse
 
 	err := json.Unmarshal(body, &resp)
 
 	if err != nil {
 		log.Errorf("newpusher android: Parse reply error %s %s", err, string(body))
-		return push.ErrorServiceUnavailable
+		return "", push.ErrorServiceUnavailable
 	}
 
 	if resp.Code > 0 {
 		log.Errorf("newpusher android: bad reply %s", string(body))
 		switch resp.Status {
 		case unregistered:
-			return push.ErrorTokenRemoved
+			return "", push.ErrorTokenRemoved
 		case unauth:
-			return push.ErrorRefreshToken
+			return "", push.ErrorRefreshToken
 		default:
-			return push.ErrorServiceUnavailable
+			return "", push.ErrorServiceUnavailable
 		}
 	}
 
 	fmt.Println(string(body))
 
-	return nil
+	return resp.Name, nil
 }
 
 func (sender *AndroidSender) GetToken(opts *FcmMessageOpts, refreshToken bool) (string, error) {
@@ -137,20 +150,38 @@ func (sender *AndroidSender) GetToken(opts *FcmMessageOpts, refreshToken bool) (
 }
 
 func (sender *AndroidSender) Send(to string, data map[string]string, opts *FcmMessageOpts) error {
+	fcmMsg := &FcmMessageProto{}
+	fcmMsg.Message.Data = data
+
+	_, err := sender.SendProto(to, fcmMsg, opts)
+	return err
+}
+
+// SendMessage renders unified message and sends it
+func (sender *AndroidSender) SendMessage(to string, msg *push.Message, opts *FcmMessageOpts) error {
+	if opts == nil || msg == nil {
+		log.Errorf("newpusher android: opts not valid [%#v] [%#v]", opts, msg)
+		return push.ErrorRequest
+	}
+
+	_, err := sender.SendProto(to, RenderMessage(msg, opts), opts)
+	return err
+}
+
+// SendProto sends prepared message and returns fcm message name
+func (sender *AndroidSender) SendProto(to string, fcmMsg *FcmMessageProto, opts *FcmMessageOpts) (string, error) {
 	if opts == nil || !opts.Valid() || to == "" {
 		log.Errorf("newpusher android: opts not valid [%#v] [%s]", opts, to)
-		return push.ErrorRequest
+		return "", push.ErrorRequest
 	}
 
-	fcmMsg := FcmMessageProto{}
-	fcmMsg.Message.Data = data
 	fcmMsg.Message.Token = to
 
-	j, err := json.Marshal(&fcmMsg)
+	j, err := json.Marshal(fcmMsg)
 
 	if err != nil {
 		log.Errorf("newpusher android: cannot j, err := json.Marshal(&msg) %s", err)
-		return push.ErrorRequest
+		return "", push.ErrorRequest
 	}
 
 	refreshToken := false
@@ -161,14 +192,14 @@ func (sender *AndroidSender) Send(to string, data map[string]string, opts *FcmMe
 
 		if err != nil {
 			log.Errorf("newpusher android: cannot read options %s", err)
-			return push.ErrorRequest
+			return "", push.ErrorRequest
 		}
 
 		apiKey, err := sender.GetToken(opts, refreshToken)
 
 		if err != nil {
 			log.Errorf("new pusher cannot get push token %s", err)
-			return err
+			return "", err
 		}
 
 		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", apiKey))
@@ -186,44 +217,45 @@ func (sender *AndroidSender) Send(to string, data map[string]string, opts *FcmMe
 
 		if err != nil {
 			log.Errorf("newpusher android: cannot send request [%#v] %s", fcmMsg, err)
-			return push.ErrorRequest
+			return "", push.ErrorRequest
 		}
 
 		body, err := io.ReadAll(resp.Body)
 
 		if err != nil {
 			log.Errorf("newpusher android: cannot read data %s", err)
-			return push.ErrorServiceUnavailable
+			return "", push.ErrorServiceUnavailable
 		}
 
 		switch resp.StatusCode {
 		case 401:
-			if err = parseReply(body); errors.Is(err, push.ErrorRefreshToken) {
+			if _, err = parseReply(body); errors.Is(err, push.ErrorRefreshToken) {
 				refreshToken = true
 				continue
 			} else {
 				log.Errorf("newpusher android: ErrorInvalidKey %s %s [%#v]", resp.Status, string(body), fcmMsg)
-				return err
+				return "", err
 			}
 		case 400, 500:
 			log.Errorf("newpusher android: ServiceUnavailable %s %s [%#v]", resp.Status, string(body), fcmMsg)
-			return push.ErrorServiceUnavailable
+			return "", push.ErrorServiceUnavailable
 		}
 
-		err = parseReply(body)
+		var messageId string
+		messageId, err = parseReply(body)
 
 		if err != nil && errors.Is(err, push.ErrorTokenRemoved) {
-			return err
+			return "", err
 		}
 		if err != nil {
 			refreshToken = true
 		} else {
-			return nil
+			return messageId, nil
 		}
 
 	}
 
-	return err
+	return "", err
 }
 
 /**
@@ -2,6 +2,8 @@ package android
 
 import (
 	"testing"
+
+	"push-sender/internal/push"
 )
 
 const testData = `{"type":"notification","user_id":""}`
@@ -40,3 +42,26 @@ func TestPush(t *testing.T) {
 	}
 
 }
+
+func TestRenderMessage(t *testing.T) {
+	msg, ok := push.ParseMessage(`{"unified":{"title":"hi","body":"there","priority":"high","ttl":60,"data":{"id":"1"}}}`)
+	if !ok {
+		t.Fatal("unified message not recognized")
+	}
+
+	fcmMsg := RenderMessage(msg, &FcmMessageOpts{TimeToLive: 3600})
+
+	if fcmMsg.Message.Notification == nil || fcmMsg.Message.Notification.Title != "hi" {
+		t.Errorf("bad notification %#v", fcmMsg.Message.Notification)
+	}
+	if fcmMsg.Message.Android.Priority != "high" || fcmMsg.Message.Android.TTL != "60s" {
+		t.Errorf("bad android config %#v", fcmMsg.Message.Android)
+	}
+	if fcmMsg.Message.Data["id"] != "1" {
+		t.Errorf("bad data %#v", fcmMsg.Message.Data)
+	}
+
+	if _, ok := push.ParseMessage(`{"aps":{}}`); ok {
+		t.Error("raw payload recognized as unified")
+	}
+}
@@ -0,0 +1,51 @@
+package android
+
+import (
+	"fmt"
+
+	"push-sender/internal/push"
+)
+
+// RenderMessage translates unified message to fcm v1 message
+func RenderMessage(msg *push.Message, opts *FcmMessageOpts) *FcmMessageProto {
+	fcmMsg := &FcmMessageProto{}
+
+	fcmMsg.Message.Data = msg.Data
+
+	if msg.Title != "" || msg.Body != "" || msg.Image != "" {
+		fcmMsg.Message.Notification = &Notification{
+			Title: msg.Title,
+			Body:  msg.Body,
+			Image: msg.Image,
+		}
+	}
+
+	android := &AndroidConfig{
+		CollapseKey: msg.CollapseKey,
+		Priority:    string(AndroidNormalPriority),
+	}
+
+	if msg.IsHigh() {
+		android.Priority = string(AndroidHighPriority)
+	}
+
+	ttl := opts.TimeToLive
+	if msg.TTL > 0 {
+		ttl = msg.TTL
+	}
+	if ttl > 0 {
+		android.TTL = fmt.Sprintf("%ds", ttl)
+	}
+
+	if msg.Sound != "" || msg.Badge != nil || msg.Category != "" {
+		android.Notification = &AndroidNotification{
+			Sound:             msg.Sound,
+			ClickAction:       msg.Category,
+			NotificationCount: msg.Badge,
+		}
+	}
+
+	fcmMsg.Message.Android = android
+
+	return fcmMsg
+}
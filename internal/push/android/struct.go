@@ -67,6 +67,10 @@ type Notification struct {
 
 	// The notification's body text.
 	Body string `json:"body,omitempty"`
+
+	// Contains the URL of an image that is going to be downloaded on the device
+	// and displayed in a notification.
+	Image string `json:"image,omitempty"`
 }
 
 // AndroidNotification represents a notification to send to android devices.
@@ -122,6 +126,10 @@ type AndroidNotification struct {
 	// in title_loc_key to use to localize the title text to the user's
 	// current localization.
 	TitleLocArgs []string `json:"title_loc_args,omitempty"`
+
+	// Sets the number of items this notification represents. May be displayed
+	// as a badge count for launchers that support badging.
+	NotificationCount *int `json:"notification_count,omitempty"`
 }
 
 // AndroidConfig represents android specific options for messages sent through FCM connection server.
@@ -0,0 +1,99 @@
+package honor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+type HonorTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	TokenType        string `json:"token_type,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type HonorToken struct {
+	AccessToken string
+	Expired     time.Time
+}
+
+func (sender *HonorSender) RefreshToken(opts *HonorMessageOpts) (string, error) {
+	postData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {opts.ClientId},
+		"client_secret": {opts.ClientSecret},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, config.GetString("honor_oauth_api"), bytes.NewBufferString(postData.Encode()))
+
+	if err != nil {
+		log.Errorf("newpusher honor: cannot make request %s", err)
+		return "", push.ErrorRequest
+	}
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	client := http.Client{Transport: transport}
+
+	resp, err := client.Do(request)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("newpusher honor: cannot send request %s", err)
+		return "", push.ErrorTransportProblem
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != 200 {
+		log.Errorf("newpusher honor: cannot status request %s [%s]", resp.Status, string(body))
+		return "", push.OAuthStatusError(resp.StatusCode)
+	}
+
+	var token HonorTokenResponse
+
+	err = json.Unmarshal(body, &token)
+
+	if err != nil || token.AccessToken == "" {
+		log.Errorf("newpusher honor: cannot unmarshal resp request %s", body)
+		return "", push.ErrorServiceUnavailable
+	}
+
+	sender.Tokens[opts.ClientId] = &HonorToken{
+		AccessToken: token.AccessToken,
+		Expired:     time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+
+	return token.AccessToken, nil
+}
+
+type HonorSender struct {
+	Tokens map[string]*HonorToken
+}
+
+func New() *HonorSender {
+	return &HonorSender{
+		Tokens: make(map[string]*HonorToken),
+	}
+}
+
+func (sender *HonorSender) GetAuthToken(opts *HonorMessageOpts) (string, error) {
+	if token, ok := sender.Tokens[opts.ClientId]; ok && time.Until(token.Expired) > time.Minute {
+		return token.AccessToken, nil
+	}
+	return sender.RefreshToken(opts)
+}
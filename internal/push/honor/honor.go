@@ -0,0 +1,225 @@
+package honor
+
+/**
+ *	Implemented honor push messaging HTTP Proto
+ */
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+var transport *http.Transport
+
+// message body size limit, excluding token
+const MAX_SIZE = 4096
+
+func init() {
+	config.SetDefault("honor_send_api", "https://push-api.cloud.honor.com/api/v1/%s/sendMessage")
+	config.SetDefault("honor_oauth_api", "https://iam.developer.honor.com/auth/token")
+	transport = &http.Transport{
+		MaxIdleConnsPerHost: 10,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     1 * time.Second,
+		TLSClientConfig:     &tls.Config{},
+	}
+}
+
+type HonorAndroidConfig struct {
+	TTL string `json:"ttl,omitempty"`
+}
+
+type HonorNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+type HonorMessage struct {
+	Data         string              `json:"data"`
+	Notification *HonorNotification  `json:"notification,omitempty"`
+	Android      *HonorAndroidConfig `json:"android,omitempty"`
+	Token        []string            `json:"token"`
+}
+
+type HonorMessageOpts struct {
+	// app id, used in the send url
+	AppId        string
+	ClientId     string
+	ClientSecret string
+	// seconds honor keeps message for offline device, 0 means provider default
+	TimeToLive int
+}
+
+type HonorMessageResponse struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
+		SendResult   bool     `json:"sendResult"`
+		RequestId    string   `json:"requestId"`
+		FailTokens   []string `json:"failTokens,omitempty"`
+		ExpireTokens []string `json:"expireTokens,omitempty"`
+	} `json:"data"`
+}
+
+func (opts *HonorMessageOpts) Valid() bool {
+	return opts.AppId != "" && opts.ClientId != "" && opts.ClientSecret != ""
+}
+
+func (sender *HonorSender) Send(to string, data string, opts *HonorMessageOpts) error {
+	if opts == nil || !opts.Valid() || to == "" {
+		log.Errorf("newpusher honor: opts not valid [%#v] [%s]", opts, to)
+		return push.ErrorRequest
+	}
+
+	return sender.send(&HonorMessage{Data: data, Token: []string{to}}, opts)
+}
+
+// SendMessage renders unified message and sends it
+func (sender *HonorSender) SendMessage(to string, msg *push.Message, opts *HonorMessageOpts) error {
+	if opts == nil || !opts.Valid() || msg == nil || to == "" {
+		log.Errorf("newpusher honor: opts not valid [%#v] [%s]", opts, to)
+		return push.ErrorRequest
+	}
+
+	honorMsg := RenderMessage(msg)
+	honorMsg.Token = []string{to}
+
+	return sender.send(honorMsg, opts)
+}
+
+func (sender *HonorSender) send(msg *HonorMessage, opts *HonorMessageOpts) error {
+	if opts.TimeToLive > 0 && msg.Android == nil {
+		msg.Android = &HonorAndroidConfig{TTL: fmt.Sprintf("%ds", opts.TimeToLive)}
+	}
+
+	j, err := json.Marshal(&msg)
+
+	if err != nil {
+		log.Errorf("newpusher honor: cannot j, err := json.Marshal(&msg) %s", err)
+		return push.ErrorRequest
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := sender.GetAuthToken(opts)
+
+		if err != nil {
+			log.Errorf("newpusher honor: get auth token %s", err)
+			// rejected credentials are permanent, oauth outage is retried
+			return err
+		}
+
+		request, err := http.NewRequest(http.MethodPost, fmt.Sprintf(config.GetString("honor_send_api"), opts.AppId), bytes.NewBuffer(j))
+
+		if err != nil {
+			log.Errorf("newpusher honor: cannot read options %s", err)
+			return push.ErrorRequest
+		}
+		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		request.Header.Add("Content-Type", "application/json")
+		request.Header.Add("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+
+		client := http.Client{Transport: transport}
+
+		resp, err := client.Do(request)
+
+		defer func() {
+			if resp != nil && resp.Body != nil {
+				resp.Body.Close()
+			}
+		}()
+
+		if err != nil {
+			log.Errorf("newpusher honor: cannot send request %s", err)
+			return push.ErrorTransportProblem
+		}
+
+		body, _ := io.ReadAll(resp.Body)
+
+		switch resp.StatusCode {
+		case 401:
+			log.Errorf("newpusher honor: refresh token %s %v", resp.Status, string(body))
+			delete(sender.Tokens, opts.ClientId)
+			continue
+		case 400:
+			log.Errorf("newpusher honor: bad message %s %s", resp.Status, string(body))
+			return push.ErrorRequest
+		case 404, 500, 502:
+			log.Errorf("newpusher honor: ServiceUnavailable %s %s", resp.Status, string(body))
+			return push.ErrorServiceUnavailable
+		case 429, 503:
+			log.Errorf("newpusher honor: ratelimit %s %s", resp.Status, string(body))
+			return push.ErrorRateLimit
+		}
+
+		err = parseReply(body)
+
+		if errors.Is(err, push.ErrorRefreshToken) {
+			delete(sender.Tokens, opts.ClientId)
+			continue
+		}
+
+		return err
+	}
+
+	return push.ErrorServiceUnavailable
+}
+
+func parseReply(body []byte) error {
+	var resp HonorMessageResponse
+	err := json.Unmarshal(body, &resp)
+	if err != nil {
+		log.Errorf("newpusher honor: parse reponse error %s %s", err, body)
+		return push.ErrorServiceUnavailable
+	}
+
+	switch resp.Code {
+	case 200:
+		if len(resp.Data.FailTokens) > 0 || len(resp.Data.ExpireTokens) > 0 {
+			log.Errorf("honor: token bad %#v", resp)
+			return push.ErrorTokenRemoved
+		}
+		return nil
+	case 80200001, 80200003:
+		log.Infof("newpusher honor: oauth expired %#v", resp)
+		return push.ErrorRefreshToken
+	case 80300002:
+		return push.ErrorPerissionDenied
+	case 80300007:
+		return push.ErrorTokenRemoved
+	case 80100003, 80300008:
+		log.Errorf("honor: bad message %#v", resp)
+		return push.ErrorRequest
+	case 81000001:
+		return push.ErrorServiceUnavailable
+	default:
+		log.Errorf("honor: response error is %#v", resp)
+		return fmt.Errorf("honor: error [%w]", push.PushError(strconv.Itoa(resp.Code)))
+	}
+}
+
+/**
+https://developer.honor.com/cn/docs/11002/reference/downlink-message
+
+200      Success.
+80000003 Incorrect request parameter.
+80100003 Incorrect message structure.
+80200001 OAuth authentication error.
+80200003 OAuth token expired.
+80300002 The app does not have the permission to send messages.
+80300007 All tokens are invalid.
+80300008 The message body size exceeds the default value (4096 bytes).
+81000001 Internal system error.
+*/
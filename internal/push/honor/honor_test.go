@@ -0,0 +1,116 @@
+package honor
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
+)
+
+func TestHonor(t *testing.T) {
+	reply := `{"code":200,"message":"success","data":{"sendResult":true,"requestId":"xxx"}}`
+	auths := 0
+	var body []byte
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		auths++
+		fmt.Fprintf(w, `{"access_token":"token%d","expires_in":3600}`, auths)
+	})
+	mux.HandleFunc("/api/v1/111/sendMessage", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != fmt.Sprintf("Bearer token%d", auths) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ = io.ReadAll(r.Body)
+		fmt.Fprint(w, reply)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	config.Set("honor_oauth_api", srv.URL+"/auth/token")
+	config.Set("honor_send_api", srv.URL+"/api/v1/%s/sendMessage")
+
+	opts := &HonorMessageOpts{
+		AppId:        "111",
+		ClientId:     "client",
+		ClientSecret: "secret",
+	}
+
+	sender := New()
+
+	if err := sender.Send("xxxxxxxxx", `{"data":{}}`, opts); err != nil {
+		t.Error(err)
+	}
+
+	reply = `{"code":80200003,"message":"token expired"}`
+	if err := sender.Send("xxxxxxxxx", `{"data":{}}`, opts); !errors.Is(err, push.ErrorServiceUnavailable) {
+		t.Errorf("expected service unavailable after refresh, got %s", err)
+	}
+	if auths != 2 {
+		t.Errorf("expected token refresh, got %d auths", auths)
+	}
+
+	reply = `{"code":80300007,"message":"invalid token"}`
+	if err := sender.Send("xxxxxxxxx", `{"data":{}}`, opts); !errors.Is(err, push.ErrorTokenRemoved) {
+		t.Errorf("expected token removed, got %s", err)
+	}
+
+	reply = `{"code":200,"message":"success","data":{"sendResult":true,"requestId":"xxx"}}`
+	opts.TimeToLive = 60
+	if err := sender.Send("xxxxxxxxx", `{"data":{}}`, opts); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(string(body), `"android":{"ttl":"60s"}`) {
+		t.Errorf("ttl is not sent %s", body)
+	}
+}
+
+func TestHonorErrors(t *testing.T) {
+	authStatus := http.StatusOK
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/auth/token", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(authStatus)
+		fmt.Fprint(w, `{"access_token":"token","expires_in":3600}`)
+	})
+	mux.HandleFunc("/api/v1/111/sendMessage", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	config.Set("honor_oauth_api", srv.URL+"/auth/token")
+	config.Set("honor_send_api", srv.URL+"/api/v1/%s/sendMessage")
+
+	opts := &HonorMessageOpts{AppId: "111", ClientId: "client", ClientSecret: "secret"}
+
+	// malformed message is not retried
+	if err := New().Send("xxxxxxxxx", `{"data":{}}`, opts); !errors.Is(err, push.ErrorRequest) {
+		t.Errorf("expected bad request, got %s", err)
+	}
+
+	// oauth outage is retried, rejected credentials are not
+	authStatus = http.StatusServiceUnavailable
+	if err := New().Send("xxxxxxxxx", `{"data":{}}`, opts); err == nil || push.IsPermanent(err) {
+		t.Errorf("expected temporary error on oauth outage, got %v", err)
+	}
+	authStatus = http.StatusUnauthorized
+	if err := New().Send("xxxxxxxxx", `{"data":{}}`, opts); !errors.Is(err, push.ErrorInvalidKey) {
+		t.Errorf("expected invalid key, got %s", err)
+	}
+
+	srv.Close()
+	if err := New().Send("xxxxxxxxx", `{"data":{}}`, opts); err == nil || push.IsPermanent(err) {
+		t.Errorf("expected temporary error on unreachable oauth, got %v", err)
+	}
+}
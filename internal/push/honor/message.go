@@ -0,0 +1,32 @@
+package honor
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"push-sender/internal/push"
+)
+
+// RenderMessage translates unified message to honor message, recipient is set by caller
+func RenderMessage(msg *push.Message) *HonorMessage {
+	honorMsg := &HonorMessage{}
+
+	if len(msg.Data) > 0 {
+		data, _ := json.Marshal(msg.Data)
+		honorMsg.Data = string(data)
+	}
+
+	if msg.Title != "" || msg.Body != "" || msg.Image != "" {
+		honorMsg.Notification = &HonorNotification{
+			Title: msg.Title,
+			Body:  msg.Body,
+			Image: msg.Image,
+		}
+	}
+
+	if msg.TTL > 0 {
+		honorMsg.Android = &HonorAndroidConfig{TTL: fmt.Sprintf("%ds", msg.TTL)}
+	}
+
+	return honorMsg
+}
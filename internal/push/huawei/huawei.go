@@ -22,6 +22,9 @@ import (
 
 var transport *http.Transport
 
+// message body size limit, excluding token
+const MAX_SIZE = 4096
+
 func init() {
 	config.SetDefault("hms_send_api", "https://159.138.203.26/v1/%s/messages:send")
 	config.SetDefault("hms_send_host", "push-api.cloud.huawei.com")
@@ -36,8 +39,10 @@ func init() {
 }
 
 type InnerMessage struct {
-	Data  string   `json:"data"`
-	Token []string `json:"token"`
+	Data         string            `json:"data,omitempty"`
+	Notification *HmsNotification  `json:"notification,omitempty"`
+	Android      *HmsAndroidConfig `json:"android,omitempty"`
+	Token        []string          `json:"token"`
 }
 
 type HmsMessage struct {
@@ -62,25 +67,39 @@ func (opts *HmsMessageOpts) Valid() bool {
 }
 
 func (sender *HmsSender) Send(to string, data string, opts *HmsMessageOpts) error {
+	/* jData, _ := json.Marshal(&data) */
+
+	_, err := sender.SendInner(to, &InnerMessage{Data: string(data)}, opts)
+	return err
+}
+
+// SendMessage renders unified message and sends it
+func (sender *HmsSender) SendMessage(to string, msg *push.Message, opts *HmsMessageOpts) error {
+	if msg == nil {
+		return push.ErrorRequest
+	}
+	_, err := sender.SendInner(to, RenderMessage(msg), opts)
+	return err
+}
+
+// SendInner sends prepared message and returns hms request id
+func (sender *HmsSender) SendInner(to string, inner *InnerMessage, opts *HmsMessageOpts) (string, error) {
 	if opts == nil || !opts.Valid() || to == "" {
 		log.Errorf("newpusher hms: opts not valid [%#v] [%s]", opts, to)
-		return push.ErrorRequest
+		return "", push.ErrorRequest
 	}
 
-	/* jData, _ := json.Marshal(&data) */
+	inner.Token = []string{to}
 
 	msg := &HmsMessage{
-		Message: InnerMessage{
-			Data:  string(data),
-			Token: []string{to},
-		},
+		Message: *inner,
 	}
 
 	j, err := json.Marshal(&msg)
 
 	if err != nil {
 		log.Errorf("newpusher hms: cannot j, err := json.Marshal(&msg) %s", err)
-		return push.ErrorRequest
+		return "", push.ErrorRequest
 	}
 
 	for i := 0; i < 2; i++ {
@@ -88,14 +107,14 @@ func (sender *HmsSender) Send(to string, data string, opts *HmsMessageOpts) erro
 
 		if err != nil {
 			log.Errorf("newpusher hms: get auth token %s", err)
-			return push.ErrorInvalidKey
+			return "", push.ErrorInvalidKey
 		}
 
 		request, err := http.NewRequest(http.MethodPost, fmt.Sprintf(config.GetString("hms_send_api"), opts.ClientId), bytes.NewBuffer(j))
 
 		if err != nil {
 			log.Errorf("newpusher hms: cannot read options %s", err)
-			return push.ErrorRequest
+			return "", push.ErrorRequest
 		}
 		request.Header.Add("Host", config.GetString("hms_send_host"))
 		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
@@ -113,7 +132,7 @@ func (sender *HmsSender) Send(to string, data string, opts *HmsMessageOpts) erro
 
 		if err != nil {
 			log.Errorf("newpusher hms: cannot send request %s", err)
-			return push.ErrorRequest
+			return "", push.ErrorRequest
 		}
 
 		body, _ := io.ReadAll(resp.Body)
@@ -125,46 +144,47 @@ func (sender *HmsSender) Send(to string, data string, opts *HmsMessageOpts) erro
 			continue
 		case 400, 404, 500, 502:
 			log.Errorf("newpusher hms: ServiceUnavailable %s %s", resp.Status, string(body))
-			return push.ErrorServiceUnavailable
+			return "", push.ErrorServiceUnavailable
 		case 503:
 			log.Errorf("newpusher hms: ratelimit %s %s", resp.Status, string(body))
-			return push.ErrorRateLimit
+			return "", push.ErrorRateLimit
 		}
 
-		err = parseReply(string(body))
+		requestId, err := parseReply(string(body))
 
 		if errors.Is(err, push.ErrorRefreshToken) {
 			sender.RefreshToken(opts)
 			continue
 		}
 
-		return err
+		return requestId, err
 	}
 
-	return push.ErrorServiceUnavailable
+	return "", push.ErrorServiceUnavailable
 }
 
-func parseReply(body string) error {
+// parseReply returns hms request id of accepted message
+func parseReply(body string) (string, error) {
 	var resp HmsMessageResponse
 	err := json.Unmarshal([]byte(body), &resp)
 	if err != nil {
 		log.Errorf("newpusher hms: parse reponse error %s %s", err, body)
-		return push.ErrorServiceUnavailable
+		return "", push.ErrorServiceUnavailable
 	}
 
 	switch resp.Code {
 	case "80000000":
-		return nil
+		return resp.RequestId, nil
 	case "80100000":
-		return parseMsg(resp.Msg)
+		return resp.RequestId, parseMsg(resp.Msg)
 	case "80200001", "80200003":
 		log.Infof("newpusher hms: oauth expired %#v", resp)
-		return push.ErrorRefreshToken
+		return "", push.ErrorRefreshToken
 	case "80300007":
-		return push.ErrorTokenRemoved
+		return "", push.ErrorTokenRemoved
 	default:
 		log.Errorf("hms: response error is %#v", resp)
-		return fmt.Errorf("hms: error [%w]", push.PushError(resp.Code))
+		return "", fmt.Errorf("hms: error [%w]", push.PushError(resp.Code))
 	}
 }
 
@@ -0,0 +1,86 @@
+package huawei
+
+import (
+	"fmt"
+
+	"github.com/goccy/go-json"
+
+	"push-sender/internal/push"
+)
+
+const (
+	// open the app on notification click
+	clickActionOpenApp = 3
+	urgencyHigh        = "HIGH"
+	urgencyNormal      = "NORMAL"
+)
+
+type HmsNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+type HmsClickAction struct {
+	Type   int    `json:"type"`
+	Action string `json:"action,omitempty"`
+}
+
+type HmsAndroidNotification struct {
+	Title        string          `json:"title,omitempty"`
+	Body         string          `json:"body,omitempty"`
+	Image        string          `json:"image,omitempty"`
+	Sound        string          `json:"sound,omitempty"`
+	DefaultSound bool            `json:"default_sound,omitempty"`
+	ClickAction  *HmsClickAction `json:"click_action,omitempty"`
+}
+
+type HmsAndroidConfig struct {
+	Urgency      string                  `json:"urgency,omitempty"`
+	Category     string                  `json:"category,omitempty"`
+	TTL          string                  `json:"ttl,omitempty"`
+	Notification *HmsAndroidNotification `json:"notification,omitempty"`
+}
+
+// RenderMessage translates unified message to hms message
+func RenderMessage(msg *push.Message) *InnerMessage {
+	inner := &InnerMessage{}
+
+	if len(msg.Data) > 0 {
+		data, _ := json.Marshal(msg.Data)
+		inner.Data = string(data)
+	}
+
+	android := &HmsAndroidConfig{
+		Urgency:  urgencyNormal,
+		Category: msg.Category,
+	}
+
+	if msg.IsHigh() {
+		android.Urgency = urgencyHigh
+	}
+
+	if msg.TTL > 0 {
+		android.TTL = fmt.Sprintf("%ds", msg.TTL)
+	}
+
+	if msg.Title != "" || msg.Body != "" {
+		inner.Notification = &HmsNotification{
+			Title: msg.Title,
+			Body:  msg.Body,
+			Image: msg.Image,
+		}
+		android.Notification = &HmsAndroidNotification{
+			Sound:        msg.Sound,
+			DefaultSound: msg.Sound == "default",
+			ClickAction:  &HmsClickAction{Type: clickActionOpenApp},
+		}
+		if android.Notification.DefaultSound {
+			android.Notification.Sound = ""
+		}
+	}
+
+	inner.Android = android
+
+	return inner
+}
@@ -0,0 +1,264 @@
+package ios
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// apns-push-type header values
+const (
+	PushTypeAlert        = "alert"
+	PushTypeBackground   = "background"
+	PushTypeVoip         = "voip"
+	PushTypeComplication = "complication"
+	PushTypeFileProvider = "fileprovider"
+	PushTypeMdm          = "mdm"
+	PushTypeLocation     = "location"
+)
+
+// interruption-level values
+const (
+	InterruptionPassive       = "passive"
+	InterruptionActive        = "active"
+	InterruptionTimeSensitive = "time-sensitive"
+	InterruptionCritical      = "critical"
+)
+
+const (
+	MaxPayloadSize     = 4096
+	MaxVoipPayloadSize = 5120
+)
+
+var (
+	ErrPayloadTooLarge      = errors.New("apns payload too large")
+	ErrBadInterruptionLevel = errors.New("bad interruption level")
+	ErrBadRelevanceScore    = errors.New("relevance score should be in [0, 1]")
+	ErrApsCustomKey         = errors.New("aps is reserved key")
+)
+
+type Alert struct {
+	Title           string   `json:"title,omitempty"`
+	Subtitle        string   `json:"subtitle,omitempty"`
+	Body            string   `json:"body,omitempty"`
+	LaunchImage     string   `json:"launch-image,omitempty"`
+	TitleLocKey     string   `json:"title-loc-key,omitempty"`
+	TitleLocArgs    []string `json:"title-loc-args,omitempty"`
+	SubtitleLocKey  string   `json:"subtitle-loc-key,omitempty"`
+	SubtitleLocArgs []string `json:"subtitle-loc-args,omitempty"`
+	LocKey          string   `json:"loc-key,omitempty"`
+	LocArgs         []string `json:"loc-args,omitempty"`
+}
+
+// Sound is a sound name or critical alert sound dictionary
+type Sound struct {
+	Name     string  `json:"name"`
+	Critical int     `json:"critical,omitempty"`
+	Volume   float64 `json:"volume,omitempty"`
+}
+
+func (s *Sound) MarshalJSON() ([]byte, error) {
+	if s.Critical == 0 && s.Volume == 0 {
+		return json.Marshal(s.Name)
+	}
+	type sound Sound
+	return json.Marshal((*sound)(s))
+}
+
+type Aps struct {
+	Alert             *Alert   `json:"alert,omitempty"`
+	Badge             *int     `json:"badge,omitempty"`
+	Sound             *Sound   `json:"sound,omitempty"`
+	ContentAvailable  int      `json:"content-available,omitempty"`
+	MutableContent    int      `json:"mutable-content,omitempty"`
+	Category          string   `json:"category,omitempty"`
+	ThreadId          string   `json:"thread-id,omitempty"`
+	InterruptionLevel string   `json:"interruption-level,omitempty"`
+	RelevanceScore    *float64 `json:"relevance-score,omitempty"`
+	TargetContentId   string   `json:"target-content-id,omitempty"`
+
+	// live activity fields
+	Event          string         `json:"event,omitempty"`
+	ContentState   map[string]any `json:"content-state,omitempty"`
+	Timestamp      int64          `json:"timestamp,omitempty"`
+	StaleDate      int64          `json:"stale-date,omitempty"`
+	DismissalDate  int64          `json:"dismissal-date,omitempty"`
+	AttributesType string         `json:"attributes-type,omitempty"`
+	Attributes     map[string]any `json:"attributes,omitempty"`
+}
+
+// Payload is apns notification payload builder
+//
+//	payload := NewPayload().AlertTitle("Hi").AlertBody("there").Badge(1).Custom("id", 42)
+type Payload struct {
+	aps    Aps
+	custom map[string]any
+}
+
+func NewPayload() *Payload {
+	return &Payload{
+		custom: make(map[string]any),
+	}
+}
+
+func (p *Payload) alert() *Alert {
+	if p.aps.Alert == nil {
+		p.aps.Alert = &Alert{}
+	}
+	return p.aps.Alert
+}
+
+func (p *Payload) AlertTitle(title string) *Payload {
+	p.alert().Title = title
+	return p
+}
+
+func (p *Payload) AlertSubtitle(subtitle string) *Payload {
+	p.alert().Subtitle = subtitle
+	return p
+}
+
+func (p *Payload) AlertBody(body string) *Payload {
+	p.alert().Body = body
+	return p
+}
+
+func (p *Payload) AlertLaunchImage(image string) *Payload {
+	p.alert().LaunchImage = image
+	return p
+}
+
+func (p *Payload) AlertTitleLoc(key string, args ...string) *Payload {
+	p.alert().TitleLocKey = key
+	p.alert().TitleLocArgs = args
+	return p
+}
+
+func (p *Payload) AlertSubtitleLoc(key string, args ...string) *Payload {
+	p.alert().SubtitleLocKey = key
+	p.alert().SubtitleLocArgs = args
+	return p
+}
+
+func (p *Payload) AlertLoc(key string, args ...string) *Payload {
+	p.alert().LocKey = key
+	p.alert().LocArgs = args
+	return p
+}
+
+// Badge sets app icon badge, 0 removes it
+func (p *Payload) Badge(badge int) *Payload {
+	p.aps.Badge = &badge
+	return p
+}
+
+func (p *Payload) Sound(name string) *Payload {
+	p.aps.Sound = &Sound{Name: name}
+	return p
+}
+
+// CriticalSound plays sound ignoring mute switch and do not disturb, volume in [0, 1]
+func (p *Payload) CriticalSound(name string, volume float64) *Payload {
+	p.aps.Sound = &Sound{Name: name, Critical: 1, Volume: volume}
+	return p
+}
+
+func (p *Payload) ContentAvailable() *Payload {
+	p.aps.ContentAvailable = 1
+	return p
+}
+
+func (p *Payload) MutableContent() *Payload {
+	p.aps.MutableContent = 1
+	return p
+}
+
+func (p *Payload) Category(category string) *Payload {
+	p.aps.Category = category
+	return p
+}
+
+func (p *Payload) ThreadId(threadId string) *Payload {
+	p.aps.ThreadId = threadId
+	return p
+}
+
+func (p *Payload) InterruptionLevel(level string) *Payload {
+	p.aps.InterruptionLevel = level
+	return p
+}
+
+func (p *Payload) RelevanceScore(score float64) *Payload {
+	p.aps.RelevanceScore = &score
+	return p
+}
+
+func (p *Payload) TargetContentId(id string) *Payload {
+	p.aps.TargetContentId = id
+	return p
+}
+
+// Custom sets application key outside of aps dictionary
+func (p *Payload) Custom(key string, value any) *Payload {
+	p.custom[key] = value
+	return p
+}
+
+// Aps gives access to aps dictionary for fields without builder method
+func (p *Payload) Aps() *Aps {
+	return &p.aps
+}
+
+func (p *Payload) MarshalJSON() ([]byte, error) {
+	if _, ok := p.custom["aps"]; ok {
+		return nil, ErrApsCustomKey
+	}
+	m := make(map[string]any, len(p.custom)+1)
+	for k, v := range p.custom {
+		m[k] = v
+	}
+	m["aps"] = &p.aps
+	return json.Marshal(m)
+}
+
+// MaxSize returns payload limit for push type
+func MaxSize(pushType string) int {
+	if pushType == PushTypeVoip {
+		return MaxVoipPayloadSize
+	}
+	return MaxPayloadSize
+}
+
+// Validate checks aps values and size limit for push type
+func (p *Payload) Validate(pushType string) error {
+	_, err := p.Build(pushType)
+	return err
+}
+
+// Build validates payload and marshals it within size limit for push type
+func (p *Payload) Build(pushType string) (string, error) {
+	switch p.aps.InterruptionLevel {
+	case "", InterruptionPassive, InterruptionActive, InterruptionTimeSensitive, InterruptionCritical:
+	default:
+		return "", fmt.Errorf("%w: %s", ErrBadInterruptionLevel, p.aps.InterruptionLevel)
+	}
+
+	if score := p.aps.RelevanceScore; score != nil && (*score < 0 || *score > 1) {
+		return "", ErrBadRelevanceScore
+	}
+
+	if pushType == PushTypeLiveActivity {
+		if err := p.validateLiveActivity(); err != nil {
+			return "", err
+		}
+	}
+
+	j, err := json.Marshal(p)
+	if err != nil {
+		return "", err
+	}
+	if len(j) > MaxSize(pushType) {
+		return "", fmt.Errorf("%w: %d > %d", ErrPayloadTooLarge, len(j), MaxSize(pushType))
+	}
+	return string(j), nil
+}
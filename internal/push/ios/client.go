@@ -31,6 +31,75 @@ func New() *IosSender {
 }
 
 func (sender *IosSender) Send(token string, payload string, opts *ApnsOptions) error {
+	_, err := sender.SendWithHeaders(token, payload, nil, opts)
+	return err
+}
+
+// SendMessage renders unified message and sends it
+func (sender *IosSender) SendMessage(token string, msg *push.Message, opts *ApnsOptions) error {
+	payload, headers := RenderMessage(msg)
+	_, err := sender.SendPayload(token, payload, headers, opts)
+	return err
+}
+
+// SendPayload validates built payload against push type, sends it and returns apns-id
+func (sender *IosSender) SendPayload(token string, payload *Payload, headers *ApnsHeaders, opts *ApnsOptions) (string, error) {
+	pushType := PushTypeAlert
+	if headers != nil && headers.PushType != "" {
+		pushType = headers.PushType
+	}
+
+	data, err := payload.Build(pushType)
+	if err != nil {
+		log.Errorf("apns: bad payload %s", err)
+		return "", push.ErrorRequest
+	}
+
+	return sender.SendWithHeaders(token, data, headers, opts)
+}
+
+func (sender *IosSender) SendWithHeaders(token string, payload string, headers *ApnsHeaders, opts *ApnsOptions) (apnsId string, err error) {
+	err = sender.withClient(opts, func(c *Client) (err error) {
+		apnsId, err = c.SendWithHeaders(token, payload, headers, opts)
+		return
+	})
+	return
+}
+
+// Broadcast sends payload to every live activity subscribed to channel
+func (sender *IosSender) Broadcast(channelId string, payload string, headers *ApnsHeaders, opts *ApnsOptions) (apnsId string, err error) {
+	err = sender.withClient(opts, func(c *Client) (err error) {
+		apnsId, err = c.Broadcast(channelId, payload, headers, opts)
+		return
+	})
+	return
+}
+
+// CreateChannel creates broadcast channel and returns its id
+func (sender *IosSender) CreateChannel(storagePolicy int, opts *ApnsOptions) (channelId string, err error) {
+	err = sender.withClient(opts, func(c *Client) (err error) {
+		channelId, err = c.CreateChannel(storagePolicy, opts)
+		return
+	})
+	return
+}
+
+func (sender *IosSender) DeleteChannel(channelId string, opts *ApnsOptions) error {
+	return sender.withClient(opts, func(c *Client) error {
+		return c.DeleteChannel(channelId, opts)
+	})
+}
+
+func (sender *IosSender) ListChannels(opts *ApnsOptions) (channels []string, err error) {
+	err = sender.withClient(opts, func(c *Client) (err error) {
+		channels, err = c.ListChannels(opts)
+		return
+	})
+	return
+}
+
+// withClient calls f with bundle client, client is recreated on cert change or transport problem
+func (sender *IosSender) withClient(opts *ApnsOptions, f func(c *Client) error) error {
 
 	if !opts.Valid() {
 		log.Errorf("apns: options not valid %#v", opts)
@@ -39,7 +108,7 @@ func (sender *IosSender) Send(token string, payload string, opts *ApnsOptions) e
 
 	if cli, ok := sender.Clients[opts.BundleId]; ok {
 		if time.Now().Unix()-cli.Time < int64(config.GetUint64("ios_cert_check_timeout")) || cli.Cert == opts.Cert {
-			err := cli.Client.Send(token, payload, opts)
+			err := f(cli.Client)
 			if !errors.Is(err, push.ErrorTransportProblem) {
 				return err
 			}
@@ -66,5 +135,5 @@ func (sender *IosSender) Send(token string, payload string, opts *ApnsOptions) e
 
 	sender.Clients[opts.BundleId] = b
 
-	return b.Client.Send(token, payload, opts)
+	return f(b.Client)
 }
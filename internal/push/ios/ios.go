@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"time"
 
 	"golang.org/x/net/http2"
@@ -20,6 +21,10 @@ import (
 const (
 	DevelopmentGateway = "https://api.development.push.apple.com"
 	ProductionGateway  = "https://api.push.apple.com"
+
+	// broadcast channel management endpoints
+	DevelopmentManageGateway = "https://api-manage-broadcast.sandbox.push.apple.com:2195"
+	ProductionManageGateway  = "https://api-manage-broadcast.push.apple.com:2196"
 )
 
 var transport *http.Transport
@@ -47,8 +52,9 @@ func (opts *ApnsOptions) Valid() bool {
 }
 
 type Client struct {
-	http     *http.Client
-	endpoint string
+	http           *http.Client
+	endpoint       string
+	manageEndpoint string
 }
 
 type Response struct {
@@ -74,9 +80,11 @@ func NewClient(opts *ApnsOptions) (*Client, error) {
 	}
 
 	endpoint := ProductionGateway
+	manageEndpoint := ProductionManageGateway
 
 	if opts.Auth == "dev" {
 		endpoint = DevelopmentGateway
+		manageEndpoint = DevelopmentManageGateway
 	}
 
 	c := &Client{
@@ -96,21 +104,61 @@ func NewClient(opts *ApnsOptions) (*Client, error) {
 
 			Timeout: 60 * time.Second,
 		},
-		endpoint: endpoint,
+		endpoint:       endpoint,
+		manageEndpoint: manageEndpoint,
 	}
 
 	return c, nil
 }
 
+// ApnsHeaders are optional per notification request headers
+type ApnsHeaders struct {
+	// apns-push-type: alert, background, liveactivity...
+	PushType string
+	// apns-priority: 10 immediately, 5 power considerations, 1 lowest
+	Priority int
+	// apns-expiration, zero means deliver once or drop
+	Expiration time.Time
+	// apns-collapse-id
+	CollapseId string
+}
+
 // Send sends Notification to the APN service.
 func (c *Client) Send(token string, payload string, opts *ApnsOptions) error {
+	_, err := c.SendWithHeaders(token, payload, nil, opts)
+	return err
+}
+
+// SendWithHeaders sends Notification with additional apns headers and returns apns-id.
+func (c *Client) SendWithHeaders(token string, payload string, headers *ApnsHeaders, opts *ApnsOptions) (string, error) {
 	req, err := c.prepareRequest(token, payload, opts)
 	if err != nil {
-		return err
+		return "", err
+	}
+	if headers != nil {
+		headers.apply(req)
 	}
 	return c.do(req)
 }
 
+func (h *ApnsHeaders) apply(req *http.Request) {
+	if h.PushType != "" {
+		req.Header.Set("apns-push-type", h.PushType)
+	}
+	if h.PushType == PushTypeLiveActivity && req.Header.Get("apns-topic") != "" {
+		req.Header.Set("apns-topic", req.Header.Get("apns-topic")+LiveActivityTopicSuffix)
+	}
+	if h.Priority > 0 {
+		req.Header.Set("apns-priority", strconv.Itoa(h.Priority))
+	}
+	if !h.Expiration.IsZero() {
+		req.Header.Set("apns-expiration", strconv.FormatInt(h.Expiration.Unix(), 10))
+	}
+	if h.CollapseId != "" {
+		req.Header.Set("apns-collapse-id", h.CollapseId)
+	}
+}
+
 func (c *Client) prepareRequest(token string, payload string, opts *ApnsOptions) (*http.Request, error) {
 	req, err := http.NewRequest(
 		"POST",
@@ -129,7 +177,8 @@ func (c *Client) prepareRequest(token string, payload string, opts *ApnsOptions)
 	return req, nil
 }
 
-func (c *Client) do(req *http.Request) error {
+// do executes request and returns apns-id assigned to notification
+func (c *Client) do(req *http.Request) (string, error) {
 	resp, err := c.http.Do(req)
 
 	defer func() {
@@ -140,24 +189,24 @@ func (c *Client) do(req *http.Request) error {
 
 	if err != nil {
 		log.Errorf("apns: cannot send push to request %s", err)
-		return push.ErrorTransportProblem
+		return "", push.ErrorTransportProblem
 	}
 
 	if resp.StatusCode == http.StatusOK {
 		log.Debugf("apns: succes send %v", resp)
-		return nil
+		return resp.Header.Get("apns-id"), nil
 	}
 
 	if resp.StatusCode == http.StatusForbidden {
 		log.Errorf("apns: forriben error %#v", resp)
-		return push.ErrorInvalidKey
+		return "", push.ErrorInvalidKey
 	}
 
 	var response Response
 
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
 		log.Errorf("anps: strange bad response %v", resp.Body)
-		return push.ErrorServiceUnavailable
+		return "", push.ErrorServiceUnavailable
 	}
 
 	log.Errorf("apns: error request %s", response.Reason)
@@ -165,10 +214,10 @@ func (c *Client) do(req *http.Request) error {
 	if response.Reason == "BadDeviceToken" ||
 		response.Reason == "MissingDeviceToken" ||
 		response.Reason == "Unregistered" {
-		return push.ErrorTokenRemoved
+		return "", push.ErrorTokenRemoved
 	}
 
-	return fmt.Errorf("apns error [%w]", push.PushError(response.Reason))
+	return "", fmt.Errorf("apns error [%w]", push.PushError(response.Reason))
 }
 
 /*
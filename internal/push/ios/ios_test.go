@@ -1,9 +1,18 @@
 package ios
 
 import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
+	"time"
 
 	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
 )
 
 func init() {
@@ -11,4 +20,145 @@ func init() {
 }
 
 func TestMain(m *testing.M) {
+	os.Exit(m.Run())
+}
+
+func TestPayload(t *testing.T) {
+	payload := NewPayload().
+		AlertTitle("Hi").
+		AlertLoc("MSG_BODY", "Bob").
+		Badge(0).
+		CriticalSound("alarm.caf", 0.5).
+		InterruptionLevel(InterruptionCritical).
+		RelevanceScore(0.7).
+		ThreadId("chat-1").
+		Custom("id", 42)
+
+	data, err := payload.Build(PushTypeAlert)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`"badge":0`,
+		`"sound":{"name":"alarm.caf","critical":1,"volume":0.5}`,
+		`"loc-key":"MSG_BODY"`,
+		`"interruption-level":"critical"`,
+		`"thread-id":"chat-1"`,
+		`"id":42`,
+	} {
+		if !strings.Contains(data, want) {
+			t.Errorf("%s not found in %s", want, data)
+		}
+	}
+
+	if err := NewPayload().Sound("default").Validate(PushTypeAlert); err != nil {
+		t.Error(err)
+	}
+
+	big := NewPayload().AlertBody(strings.Repeat("x", MaxPayloadSize))
+	if err := big.Validate(PushTypeAlert); !errors.Is(err, ErrPayloadTooLarge) {
+		t.Errorf("expected too large, got %s", err)
+	}
+
+	voip := NewPayload().Custom("data", strings.Repeat("x", MaxPayloadSize))
+	if err := voip.Validate(PushTypeVoip); err != nil {
+		t.Errorf("voip payload should fit 5KB, got %s", err)
+	}
+}
+
+func newFakeApns(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *Client) {
+	srv := httptest.NewUnstartedServer(handler)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	return srv, &Client{
+		http:           srv.Client(),
+		endpoint:       srv.URL,
+		manageEndpoint: srv.URL,
+	}
+}
+
+func TestLiveActivity(t *testing.T) {
+	opts := &ApnsOptions{BundleId: "ru.mail.app", Cert: "xxx"}
+
+	_, client := newFakeApns(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("apns-push-type") != PushTypeLiveActivity {
+			t.Errorf("bad push type %v", r.Header)
+		}
+		if r.URL.Path == "/3/device/token" && r.Header.Get("apns-topic") != "ru.mail.app.push-type.liveactivity" {
+			t.Errorf("bad headers %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if !strings.Contains(string(body), `"event":"update"`) {
+			t.Errorf("bad body %s", body)
+		}
+		if r.URL.Path == "/4/broadcasts/apps/ru.mail.app" && r.Header.Get("apns-channel-id") != "dHN0LXNyY2gtY2hubA==" {
+			t.Errorf("bad channel %v", r.Header)
+		}
+	})
+
+	payload := NewPayload().
+		LiveActivity(LiveActivityUpdate, map[string]any{"eta": 5}).
+		StaleDate(time.Now().Add(time.Hour))
+
+	data, err := payload.Build(PushTypeLiveActivity)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := DetectHeaders(data)
+	if headers == nil || headers.PushType != PushTypeLiveActivity {
+		t.Fatalf("live activity not detected %#v", headers)
+	}
+
+	if _, err := client.SendWithHeaders("token", data, headers, opts); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := client.Broadcast(ChannelPrefix+"dHN0LXNyY2gtY2hubA==", data, headers, opts); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := NewPayload().LiveActivity(LiveActivityStart, map[string]any{}).Build(PushTypeLiveActivity); !errors.Is(err, ErrNoAttributes) {
+		t.Errorf("expected attributes required, got %s", err)
+	}
+}
+
+func TestChannels(t *testing.T) {
+	opts := &ApnsOptions{BundleId: "ru.mail.app", Cert: "xxx"}
+
+	_, client := newFakeApns(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/1/apps/ru.mail.app/channels":
+			w.Header().Set("apns-channel-id", "channel")
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodGet && r.URL.Path == "/1/apps/ru.mail.app/all-channels":
+			w.Write([]byte(`{"channels":["channel"]}`))
+		case r.Method == http.MethodDelete && r.Header.Get("apns-channel-id") == "channel":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"reason":"BadChannelId"}`))
+		}
+	})
+
+	channelId, err := client.CreateChannel(ChannelMostRecent, opts)
+	if err != nil || channelId != "channel" {
+		t.Errorf("create channel %s %s", channelId, err)
+	}
+
+	channels, err := client.ListChannels(opts)
+	if err != nil || len(channels) != 1 {
+		t.Errorf("list channels %v %s", channels, err)
+	}
+
+	if err := client.DeleteChannel(channelId, opts); err != nil {
+		t.Error(err)
+	}
+
+	if err := client.DeleteChannel("unknown", opts); !errors.Is(err, push.ErrorRequest) {
+		t.Errorf("expected bad request, got %s", err)
+	}
 }
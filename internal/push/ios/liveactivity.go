@@ -0,0 +1,224 @@
+package ios
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	PushTypeLiveActivity = "liveactivity"
+	// live activity notifications go to <bundle id>.push-type.liveactivity topic
+	LiveActivityTopicSuffix = ".push-type.liveactivity"
+
+	LiveActivityStart  = "start"
+	LiveActivityUpdate = "update"
+	LiveActivityEnd    = "end"
+
+	// task recipient prefix for broadcast to channel instead of device token
+	ChannelPrefix = "/channels/"
+
+	// message-storage-policy of broadcast channel
+	ChannelNoStorage   = 0
+	ChannelMostRecent  = 1
+	channelPushTypeLA  = "LiveActivity"
+	headerApnsChannel  = "apns-channel-id"
+	headerApnsRequest  = "apns-request-id"
+	manageChannelsPath = "%s/1/apps/%s/channels"
+)
+
+var (
+	ErrBadLiveActivityEvent = errors.New("live activity event should be start, update or end")
+	ErrNoContentState       = errors.New("live activity content-state required")
+	ErrNoAttributes         = errors.New("live activity start requires attributes-type and attributes")
+)
+
+// LiveActivity sets event with content state and timestamp of the update
+func (p *Payload) LiveActivity(event string, contentState map[string]any) *Payload {
+	p.aps.Event = event
+	p.aps.ContentState = contentState
+	p.aps.Timestamp = time.Now().Unix()
+	return p
+}
+
+// Attributes sets static attributes of live activity started remotely
+func (p *Payload) Attributes(attributesType string, attributes map[string]any) *Payload {
+	p.aps.AttributesType = attributesType
+	p.aps.Attributes = attributes
+	return p
+}
+
+func (p *Payload) StaleDate(date time.Time) *Payload {
+	p.aps.StaleDate = date.Unix()
+	return p
+}
+
+func (p *Payload) DismissalDate(date time.Time) *Payload {
+	p.aps.DismissalDate = date.Unix()
+	return p
+}
+
+func (p *Payload) validateLiveActivity() error {
+	switch p.aps.Event {
+	case LiveActivityStart:
+		if p.aps.AttributesType == "" || p.aps.Attributes == nil {
+			return ErrNoAttributes
+		}
+	case LiveActivityUpdate, LiveActivityEnd:
+	default:
+		return ErrBadLiveActivityEvent
+	}
+	if p.aps.ContentState == nil {
+		return ErrNoContentState
+	}
+	return nil
+}
+
+// DetectHeaders chooses headers for raw payload, live activity is recognized by aps event
+func DetectHeaders(payload string) *ApnsHeaders {
+	var raw struct {
+		Aps struct {
+			Event string `json:"event"`
+			Alert any    `json:"alert"`
+		} `json:"aps"`
+	}
+
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil || raw.Aps.Event == "" {
+		return nil
+	}
+
+	headers := &ApnsHeaders{
+		PushType: PushTypeLiveActivity,
+		Priority: 5,
+	}
+
+	// alerting updates and start/end should be shown immediately
+	if raw.Aps.Alert != nil || raw.Aps.Event != LiveActivityUpdate {
+		headers.Priority = 10
+	}
+
+	return headers
+}
+
+// Broadcast sends notification to channel subscribers and returns apns-id.
+func (c *Client) Broadcast(channelId string, payload string, headers *ApnsHeaders, opts *ApnsOptions) (string, error) {
+	req, err := http.NewRequest(
+		http.MethodPost,
+		fmt.Sprintf("%s/4/broadcasts/apps/%s", c.endpoint, opts.BundleId),
+		bytes.NewBufferString(payload),
+	)
+
+	if err != nil {
+		log.Errorf("apns: bad make broadcast request %#v", opts)
+		return "", push.ErrorRequest
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(headerApnsChannel, strings.TrimPrefix(channelId, ChannelPrefix))
+
+	if headers != nil {
+		headers.apply(req)
+	}
+
+	return c.do(req)
+}
+
+// CreateChannel creates live activity broadcast channel.
+func (c *Client) CreateChannel(storagePolicy int, opts *ApnsOptions) (string, error) {
+	body, _ := json.Marshal(map[string]any{
+		"message-storage-policy": storagePolicy,
+		"push-type":              channelPushTypeLA,
+	})
+
+	resp, _, err := c.manage(http.MethodPost, fmt.Sprintf(manageChannelsPath, c.manageEndpoint, opts.BundleId), body, "")
+	if err != nil {
+		return "", err
+	}
+
+	return resp.Header.Get(headerApnsChannel), nil
+}
+
+// DeleteChannel deletes broadcast channel.
+func (c *Client) DeleteChannel(channelId string, opts *ApnsOptions) error {
+	_, _, err := c.manage(http.MethodDelete, fmt.Sprintf(manageChannelsPath, c.manageEndpoint, opts.BundleId), nil, channelId)
+	return err
+}
+
+// ListChannels returns ids of all channels of the bundle.
+func (c *Client) ListChannels(opts *ApnsOptions) ([]string, error) {
+	_, body, err := c.manage(http.MethodGet, fmt.Sprintf("%s/1/apps/%s/all-channels", c.manageEndpoint, opts.BundleId), nil, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Channels []string `json:"channels"`
+	}
+
+	if err := json.Unmarshal(body, &list); err != nil {
+		log.Errorf("apns: bad channel list %s", string(body))
+		return nil, push.ErrorServiceUnavailable
+	}
+
+	return list.Channels, nil
+}
+
+func (c *Client) manage(method string, url string, body []byte, channelId string) (*http.Response, []byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(body))
+	if err != nil {
+		log.Errorf("apns: bad make manage request %s", err)
+		return nil, nil, push.ErrorRequest
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if channelId != "" {
+		req.Header.Set(headerApnsChannel, strings.TrimPrefix(channelId, ChannelPrefix))
+	}
+
+	resp, err := c.http.Do(req)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("apns: cannot send manage request %s", err)
+		return nil, nil, push.ErrorTransportProblem
+	}
+
+	reply, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, reply, nil
+	}
+
+	var response Response
+	json.Unmarshal(reply, &response)
+
+	log.Errorf("apns: manage %s %s error %s %s [%s]", method, url, resp.Status, response.Reason, resp.Header.Get(headerApnsRequest))
+
+	switch resp.StatusCode {
+	case http.StatusForbidden:
+		return nil, nil, push.ErrorInvalidKey
+	case http.StatusNotFound:
+		// unknown channel, not a removed device token
+		return nil, nil, push.ErrorRequest
+	case http.StatusTooManyRequests:
+		return nil, nil, push.ErrorRateLimit
+	case http.StatusBadRequest:
+		return nil, nil, push.ErrorRequest
+	}
+
+	return nil, nil, push.ErrorServiceUnavailable
+}
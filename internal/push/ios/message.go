@@ -0,0 +1,55 @@
+package ios
+
+import (
+	"time"
+
+	"push-sender/internal/push"
+)
+
+// RenderMessage translates unified message to aps payload and request headers
+func RenderMessage(msg *push.Message) (*Payload, *ApnsHeaders) {
+	payload := NewPayload()
+
+	if msg.Title != "" {
+		payload.AlertTitle(msg.Title)
+	}
+	if msg.Body != "" {
+		payload.AlertBody(msg.Body)
+	}
+	if msg.Sound != "" {
+		payload.Sound(msg.Sound)
+	}
+	if msg.Badge != nil {
+		payload.Badge(*msg.Badge)
+	}
+	if msg.Category != "" {
+		payload.Category(msg.Category)
+	}
+	if msg.Image != "" {
+		// notification service extension downloads the image
+		payload.MutableContent().Custom("image", msg.Image)
+	}
+	for k, v := range msg.Data {
+		payload.Custom(k, v)
+	}
+
+	headers := &ApnsHeaders{
+		PushType:   PushTypeAlert,
+		Priority:   5,
+		CollapseId: msg.CollapseKey,
+	}
+
+	aps := payload.Aps()
+	if aps.Alert == nil && aps.Sound == nil && aps.Badge == nil {
+		payload.ContentAvailable()
+		headers.PushType = PushTypeBackground
+	} else if msg.IsHigh() {
+		headers.Priority = 10
+	}
+
+	if msg.TTL > 0 {
+		headers.Expiration = time.Now().Add(time.Duration(msg.TTL) * time.Second)
+	}
+
+	return payload, headers
+}
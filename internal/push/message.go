@@ -0,0 +1,99 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// MessageKey marks task payload as unified message: {"unified": {...}},
+// payloads without it are passed to providers as is
+const MessageKey = "unified"
+
+type Priority string
+
+const (
+	PriorityNormal Priority = "normal"
+	PriorityHigh   Priority = "high"
+)
+
+// Message is a provider independent notification rendered by each transport
+type Message struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	// image url shown in expanded notification
+	Image string `json:"image,omitempty"`
+	// custom key/value payload delivered to application
+	Data  map[string]string `json:"data,omitempty"`
+	Sound string            `json:"sound,omitempty"`
+	// application icon badge, nil keeps current value
+	Badge    *int     `json:"badge,omitempty"`
+	Priority Priority `json:"priority,omitempty"`
+	// seconds provider keeps message for offline device, 0 means provider default
+	TTL int `json:"ttl,omitempty"`
+	// newer message with the same key replaces undelivered older one
+	CollapseKey string `json:"collapse_key,omitempty"`
+	// ios category, android click action or channel
+	Category string `json:"category,omitempty"`
+}
+
+func (msg *Message) IsHigh() bool {
+	return msg.Priority == PriorityHigh
+}
+
+// ParseMessage extracts unified message from task payload, false means raw provider payload
+func ParseMessage(payload any) (*Message, bool) {
+	var envelope map[string]json.RawMessage
+
+	switch v := payload.(type) {
+	case *Message:
+		return v, true
+	case string:
+		if json.Unmarshal([]byte(v), &envelope) != nil {
+			return nil, false
+		}
+	case map[string]any, map[any]any:
+		j, err := json.Marshal(normalize(v))
+		if err != nil || json.Unmarshal(j, &envelope) != nil {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+
+	raw, ok := envelope[MessageKey]
+	if !ok {
+		return nil, false
+	}
+
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, false
+	}
+
+	return &msg, true
+}
+
+// normalize converts msgpack map[any]any to json friendly map[string]any
+func normalize(v any) any {
+	switch m := v.(type) {
+	case map[any]any:
+		res := make(map[string]any, len(m))
+		for k, val := range m {
+			res[fmt.Sprintf("%v", k)] = normalize(val)
+		}
+		return res
+	case map[string]any:
+		res := make(map[string]any, len(m))
+		for k, val := range m {
+			res[k] = normalize(val)
+		}
+		return res
+	case []any:
+		res := make([]any, len(m))
+		for i, val := range m {
+			res[i] = normalize(val)
+		}
+		return res
+	}
+	return v
+}
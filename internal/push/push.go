@@ -1,6 +1,12 @@
 package push
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
 
 type PushError string
 
@@ -21,4 +27,134 @@ var (
 	ErrorRateLimit          = fmt.Errorf("error [%w]", PushError("RateLimit"))
 	ErrorRefreshToken       = fmt.Errorf("error [%w]", PushError("RefreshToken"))
 	ErrorPerissionDenied    = fmt.Errorf("error [%w]", PushError("PermissionDenied"))
+	// recipient is in suppression list, nothing was sent
+	ErrorSuppressed = fmt.Errorf("error [%w]", PushError("Suppressed"))
+	// provider circuit is open, nothing was sent
+	ErrorCircuitOpen = fmt.Errorf("error [%w]", PushError("CircuitOpen"))
+	// task lifetime passed before delivery, nothing was sent
+	ErrorExpired = fmt.Errorf("error [%w]", PushError("Expired"))
+	// message is delivered, provider asks to use another recipient id from now on
+	ErrorCanonical = fmt.Errorf("error [%w]", PushError("Canonical"))
 )
+
+// CanonicalError carries recipient id provider asks to replace the old one
+// with, e.g. canonical registration id of adm. It is reported as feedback,
+// delivery itself succeeded.
+type CanonicalError struct {
+	Err error
+	To  string
+}
+
+func NewCanonicalError(to string) error {
+	return &CanonicalError{Err: ErrorCanonical, To: to}
+}
+
+func (e *CanonicalError) Error() string {
+	return fmt.Sprintf("%s use %s", e.Err, e.To)
+}
+
+func (e *CanonicalError) Unwrap() error {
+	return e.Err
+}
+
+// CanonicalTo returns replacement recipient id if err has one
+func CanonicalTo(err error) (string, bool) {
+	var canonical *CanonicalError
+	if errors.As(err, &canonical) {
+		return canonical.To, true
+	}
+	return "", false
+}
+
+// RetryError carries the delay requested by provider before the next attempt,
+// e.g. Retry-After header on throttling.
+type RetryError struct {
+	Err   error
+	After time.Duration
+}
+
+func NewRetryError(err error, after time.Duration) error {
+	return &RetryError{Err: err, After: after}
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s retry after %s", e.Err, e.After)
+}
+
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter returns delay requested by provider if err has one
+func RetryAfter(err error) (time.Duration, bool) {
+	var retry *RetryError
+	if errors.As(err, &retry) {
+		return retry.After, true
+	}
+	return 0, false
+}
+
+// ParseRetryAfter parses Retry-After header given in seconds or http date
+func ParseRetryAfter(header string) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(header); err == nil {
+		return time.Duration(sec) * time.Second, true
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return time.Until(at), true
+	}
+	return 0, false
+}
+
+// OAuthStatusError maps failed token endpoint status: rejected credentials are
+// permanent, throttling and provider outages are retried
+func OAuthStatusError(status int) error {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return ErrorRateLimit
+	case status >= http.StatusInternalServerError:
+		return ErrorServiceUnavailable
+	default:
+		return ErrorInvalidKey
+	}
+}
+
+// IsPermanent reports the error will repeat for the same recipient and channel,
+// so retrying makes no sense but another channel may succeed
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrorTokenRemoved) ||
+		errors.Is(err, ErrorRequest) ||
+		errors.Is(err, ErrorInvalidKey) ||
+		errors.Is(err, ErrorPerissionDenied) ||
+		errors.Is(err, ErrorSuppressed) ||
+		errors.Is(err, ErrorExpired)
+}
+
+// ErrorCode returns provider error code carried by err, empty for success
+func ErrorCode(err error) string {
+	if err == nil {
+		return ""
+	}
+	var pushErr PushError
+	if errors.As(err, &pushErr) {
+		return pushErr.TransportErrorCode()
+	}
+	return "Unknown"
+}
+
+// IsOverload reports provider asks to slow down: throttling or 5xx
+func IsOverload(err error) bool {
+	if err == nil || errors.Is(err, ErrorCircuitOpen) {
+		return false
+	}
+	if _, ok := RetryAfter(err); ok {
+		return true
+	}
+	switch ErrorCode(err) {
+	case "RateLimit", "ServiceUnavailable", "TooManyRequests", "QUOTA_EXCEEDED", "InternalServerError", "Shutdown":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,59 @@
+package rustore
+
+import (
+	"fmt"
+
+	"push-sender/internal/push"
+)
+
+type RuStoreNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+	Image string `json:"image,omitempty"`
+}
+
+type RuStoreAndroidNotification struct {
+	Title       string `json:"title,omitempty"`
+	Body        string `json:"body,omitempty"`
+	Image       string `json:"image,omitempty"`
+	ChannelId   string `json:"channel_id,omitempty"`
+	ClickAction string `json:"click_action,omitempty"`
+}
+
+type RuStoreAndroidConfig struct {
+	TTL          string                      `json:"ttl,omitempty"`
+	Notification *RuStoreAndroidNotification `json:"notification,omitempty"`
+}
+
+// RenderMessage translates unified message to vk push message, recipient is set by caller
+func RenderMessage(msg *push.Message) *RuStoreMessage {
+	ruStoreMsg := &RuStoreMessage{
+		Data: msg.Data,
+	}
+
+	if msg.Title != "" || msg.Body != "" || msg.Image != "" {
+		ruStoreMsg.Notification = &RuStoreNotification{
+			Title: msg.Title,
+			Body:  msg.Body,
+			Image: msg.Image,
+		}
+	}
+
+	android := &RuStoreAndroidConfig{}
+
+	if msg.TTL > 0 {
+		android.TTL = fmt.Sprintf("%ds", msg.TTL)
+	}
+
+	if msg.Category != "" {
+		android.Notification = &RuStoreAndroidNotification{
+			ClickAction: msg.Category,
+		}
+	}
+
+	if android.TTL != "" || android.Notification != nil {
+		ruStoreMsg.Android = android
+	}
+
+	return ruStoreMsg
+}
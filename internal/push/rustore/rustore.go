@@ -41,9 +41,18 @@ func init() {
 	}
 }
 
+// TopicPrefix marks a task recipient as a topic name instead of a push token,
+// e.g. "/topics/news".
+const TopicPrefix = "/topics/"
+
 type RuStoreMessage struct {
-	Token string            `json:"token"`
-	Data  map[string]string `json:"data"`
+	// Push token of the recipient device, mutually exclusive with Topic
+	Token string `json:"token,omitempty"`
+	// Topic name to broadcast the message to, without TopicPrefix
+	Topic        string                `json:"topic,omitempty"`
+	Data         map[string]string     `json:"data,omitempty"`
+	Notification *RuStoreNotification  `json:"notification,omitempty"`
+	Android      *RuStoreAndroidConfig `json:"android,omitempty"`
 }
 
 type RuStoreProto struct {
@@ -54,6 +63,8 @@ type RuStoreProto struct {
 type RuStoreMessageOpts struct {
 	ApiKey    string
 	ProjectId string
+	// seconds rustore keeps message for offline device, 0 means provider default
+	TimeToLive int
 }
 
 func (opts *RuStoreMessageOpts) Valid() bool {
@@ -91,20 +102,58 @@ func parseReply(body []byte) error {
 	return fmt.Errorf("error [%w]", resp.Error.Status)
 }
 
+// Send sends data to a single device identified by push token.
 func Send(to string, data string, opts *RuStoreMessageOpts) error {
 	if opts == nil || !opts.Valid() || to == "" {
 		log.Errorf("newpusher rustore: opts not valid [%#v] [%s]", opts, to)
 		return push.ErrorRequest
 	}
 
-	ruStoreMsg := RuStoreProto{
-		Message: RuStoreMessage{
-			Token: to,
-			Data:  make(map[string]string),
-		},
+	return send(RuStoreMessage{Token: to, Data: map[string]string{"data": data}}, opts)
+}
+
+// SendMessage renders unified message and sends it to token or topic
+func SendMessage(to string, msg *push.Message, opts *RuStoreMessageOpts) error {
+	if opts == nil || !opts.Valid() || msg == nil || to == "" {
+		log.Errorf("newpusher rustore: opts not valid [%#v] [%s]", opts, to)
+		return push.ErrorRequest
 	}
 
-	ruStoreMsg.Message.Data["data"] = data
+	ruStoreMsg := RenderMessage(msg)
+
+	if strings.HasPrefix(to, TopicPrefix) {
+		ruStoreMsg.Topic = strings.TrimPrefix(to, TopicPrefix)
+	} else {
+		ruStoreMsg.Token = to
+	}
+
+	return send(*ruStoreMsg, opts)
+}
+
+// SendTopic broadcasts data to every device subscribed to topic.
+func SendTopic(topic string, data string, opts *RuStoreMessageOpts) error {
+	topic = strings.TrimPrefix(topic, TopicPrefix)
+	if opts == nil || !opts.Valid() || topic == "" {
+		log.Errorf("newpusher rustore: opts not valid [%#v] [%s]", opts, topic)
+		return push.ErrorRequest
+	}
+
+	return send(RuStoreMessage{Topic: topic, Data: map[string]string{"data": data}}, opts)
+}
+
+func send(msg RuStoreMessage, opts *RuStoreMessageOpts) error {
+	if opts.TimeToLive > 0 && (msg.Android == nil || msg.Android.TTL == "") {
+		android := RuStoreAndroidConfig{}
+		if msg.Android != nil {
+			android = *msg.Android
+		}
+		android.TTL = fmt.Sprintf("%ds", opts.TimeToLive)
+		msg.Android = &android
+	}
+
+	ruStoreMsg := RuStoreProto{
+		Message: msg,
+	}
 
 	j, err := json.Marshal(&ruStoreMsg)
 
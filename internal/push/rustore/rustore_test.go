@@ -1,8 +1,15 @@
 package rustore
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
 )
 
 func TestRuStore(m *testing.T) {
@@ -17,3 +24,74 @@ func TestRuStore(m *testing.T) {
 
 	fmt.Printf("%s", err)
 }
+
+func TestRuStoreTopic(t *testing.T) {
+	var got RuStoreTopicProto
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/projects/project/topic/batchSubscribe" {
+			t.Errorf("bad path %s", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer srv.Close()
+
+	config.Set("rustore_topic_api", srv.URL+"/v1/projects/{project_id}/topic/{action}")
+
+	opt := RuStoreMessageOpts{
+		ApiKey:    "xxxxxxxxxxxxxxx",
+		ProjectId: "project",
+	}
+
+	if err := Subscribe(TopicPrefix+"news", []string{"token"}, &opt); err != nil {
+		t.Error(err)
+	}
+
+	if got.TopicName != "news" || len(got.Tokens) != 1 {
+		t.Errorf("bad request %#v", got)
+	}
+}
+
+func TestRenderMessage(t *testing.T) {
+	j, _ := json.Marshal(RenderMessage(&push.Message{Title: "hi"}))
+	if string(j) != `{"notification":{"title":"hi"}}` {
+		t.Errorf("empty android config rendered %s", j)
+	}
+
+	j, _ = json.Marshal(RenderMessage(&push.Message{Title: "hi", TTL: 60}))
+	if string(j) != `{"notification":{"title":"hi"},"android":{"ttl":"60s"}}` {
+		t.Errorf("android ttl is not rendered %s", j)
+	}
+}
+
+func TestRuStoreTtl(t *testing.T) {
+	var got RuStoreProto
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{}`)
+	}))
+	defer srv.Close()
+
+	config.Set("rustore_send_api", srv.URL+"/v1/projects/{project_id}/messages:send")
+
+	opt := RuStoreMessageOpts{
+		ApiKey:     "xxxxxxxxxxxxxxx",
+		ProjectId:  "project",
+		TimeToLive: 60,
+	}
+
+	if err := Send("token", `{"id":1}`, &opt); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message.Android == nil || got.Message.Android.TTL != "60s" {
+		t.Errorf("ttl is not sent %#v", got.Message)
+	}
+
+	if err := SendMessage("token", &push.Message{Title: "hi", TTL: 30}, &opt); err != nil {
+		t.Fatal(err)
+	}
+	if got.Message.Android == nil || got.Message.Android.TTL != "30s" {
+		t.Errorf("message ttl is replaced %#v", got.Message)
+	}
+}
@@ -0,0 +1,121 @@
+package rustore
+
+/**
+ *	Implemented VK Push topic subscription management
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
+)
+
+const (
+	TopicSubscribe   = "batchSubscribe"
+	TopicUnsubscribe = "batchUnsubscribe"
+	// max tokens per one subscription request
+	MAX_TOPIC_TOKENS = 1000
+)
+
+func init() {
+	config.SetDefault("rustore_topic_api", "https://vkpns-sub.rustore.ru/v1/projects/{project_id}/topic/{action}")
+}
+
+type RuStoreTopicProto struct {
+	Tokens    []string `json:"tokens"`
+	TopicName string   `json:"topicName"`
+}
+
+// Subscribe adds tokens to topic subscribers
+func Subscribe(topic string, tokens []string, opts *RuStoreMessageOpts) error {
+	return manageTopic(TopicSubscribe, topic, tokens, opts)
+}
+
+// Unsubscribe removes tokens from topic subscribers
+func Unsubscribe(topic string, tokens []string, opts *RuStoreMessageOpts) error {
+	return manageTopic(TopicUnsubscribe, topic, tokens, opts)
+}
+
+func manageTopic(action string, topic string, tokens []string, opts *RuStoreMessageOpts) error {
+	topic = strings.TrimPrefix(topic, TopicPrefix)
+
+	if opts == nil || !opts.Valid() || topic == "" || len(tokens) == 0 {
+		log.Errorf("newpusher rustore: topic opts not valid [%#v] [%s] [%d]", opts, topic, len(tokens))
+		return push.ErrorRequest
+	}
+
+	for start := 0; start < len(tokens); start += MAX_TOPIC_TOKENS {
+		end := start + MAX_TOPIC_TOKENS
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		if err := topicRequest(action, topic, tokens[start:end], opts); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func topicRequest(action string, topic string, tokens []string, opts *RuStoreMessageOpts) error {
+	j, err := json.Marshal(&RuStoreTopicProto{
+		Tokens:    tokens,
+		TopicName: topic,
+	})
+
+	if err != nil {
+		log.Errorf("newpusher rustore: cannot marshal topic request %s", err)
+		return push.ErrorRequest
+	}
+
+	mUrl := strings.NewReplacer(
+		"{project_id}", opts.ProjectId,
+		"{action}", action,
+	).Replace(config.GetString("rustore_topic_api"))
+
+	request, err := http.NewRequest(http.MethodPost, mUrl, bytes.NewBuffer(j))
+
+	if err != nil {
+		log.Errorf("newpusher rustore: cannot make topic request %s", err)
+		return push.ErrorRequest
+	}
+
+	request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", opts.ApiKey))
+	request.Header.Add("Content-Type", "application/json")
+
+	client := http.Client{Transport: transport}
+
+	resp, err := client.Do(request)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("newpusher rustore: cannot send topic request %s %s", action, err)
+		return push.ErrorRequest
+	}
+
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		log.Errorf("newpusher rustore: cannot read topic reply %s", err)
+		return push.ErrorServiceUnavailable
+	}
+
+	if resp.StatusCode == http.StatusOK && len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	return parseReply(body)
+}
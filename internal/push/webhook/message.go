@@ -0,0 +1,13 @@
+package webhook
+
+import (
+	"encoding/json"
+
+	"push-sender/internal/push"
+)
+
+// RenderMessage translates unified message to json body of the callback
+func RenderMessage(msg *push.Message) string {
+	j, _ := json.Marshal(msg)
+	return string(j)
+}
@@ -0,0 +1,138 @@
+package webhook
+
+/**
+ *	Implemented generic signed http callback
+ */
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/containers/slices"
+	"push-sender/internal/push"
+)
+
+var transport *http.Transport
+
+const (
+	HeaderTimestamp = "X-Push-Timestamp"
+	HeaderSignature = "X-Push-Signature"
+	HeaderProject   = "X-Push-Project"
+)
+
+func init() {
+	config.SetDefault("webhook_timeout", 5*time.Second)
+	config.SetDefault("webhook_success_codes", []int{200, 201, 202, 204})
+	transport = &http.Transport{
+		MaxIdleConnsPerHost: 10,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     1 * time.Second,
+		TLSClientConfig:     &tls.Config{},
+	}
+}
+
+type WebhookOpts struct {
+	ProjectId string
+	// hmac-sha256 key, request is not signed if empty
+	Secret       string
+	Timeout      time.Duration
+	SuccessCodes []int
+	// additional static headers, e.g. api tokens of consumer
+	Headers map[string]string
+}
+
+func (opts *WebhookOpts) Valid() bool {
+	return opts.Timeout > 0 && len(opts.SuccessCodes) > 0
+}
+
+// Sign returns hex hmac-sha256 of "timestamp.body", consumers verify it the same way
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func Send(to string, data string, opts *WebhookOpts) error {
+	if opts == nil || !opts.Valid() {
+		log.Errorf("newpusher webhook: opts not valid [%#v] [%s]", opts, to)
+		return push.ErrorRequest
+	}
+
+	if u, err := url.Parse(to); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Errorf("newpusher webhook: bad url [%s]", to)
+		return push.ErrorRequest
+	}
+
+	body := []byte(data)
+
+	request, err := http.NewRequest(http.MethodPost, to, bytes.NewBuffer(body))
+
+	if err != nil {
+		log.Errorf("newpusher webhook: cannot make request %s", err)
+		return push.ErrorRequest
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	request.Header.Add("Content-Type", "application/json")
+	request.Header.Add(HeaderProject, opts.ProjectId)
+	request.Header.Add(HeaderTimestamp, timestamp)
+	if opts.Secret != "" {
+		request.Header.Add(HeaderSignature, Sign(opts.Secret, timestamp, body))
+	}
+	for k, v := range opts.Headers {
+		request.Header.Set(k, v)
+	}
+
+	client := http.Client{Transport: transport, Timeout: opts.Timeout}
+
+	resp, err := client.Do(request)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("newpusher webhook: cannot send request %s %s", to, err)
+		return push.ErrorTransportProblem
+	}
+
+	if slices.Contains(opts.SuccessCodes, resp.StatusCode) {
+		return nil
+	}
+
+	reply, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+
+	log.Errorf("newpusher webhook: bad reply %s %s %s", to, resp.Status, string(reply))
+
+	switch {
+	case resp.StatusCode == http.StatusGone:
+		return push.ErrorTokenRemoved
+	case resp.StatusCode == http.StatusTooManyRequests:
+		if after, ok := push.ParseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			return push.NewRetryError(push.ErrorRateLimit, after)
+		}
+		return push.ErrorRateLimit
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		return push.ErrorInvalidKey
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return push.ErrorRequest
+	}
+
+	return push.ErrorServiceUnavailable
+}
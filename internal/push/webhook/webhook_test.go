@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"push-sender/internal/push"
+)
+
+func TestWebhook(t *testing.T) {
+	status := http.StatusAccepted
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderSignature) != Sign("secret", r.Header.Get(HeaderTimestamp), body) {
+			t.Errorf("bad signature %v", r.Header)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	opts := &WebhookOpts{
+		ProjectId:    "project",
+		Secret:       "secret",
+		Timeout:      time.Second,
+		SuccessCodes: []int{http.StatusAccepted},
+	}
+
+	if err := Send(srv.URL, `{"text":"hello"}`, opts); err != nil {
+		t.Error(err)
+	}
+
+	status = http.StatusGone
+	if err := Send(srv.URL, `{"text":"hello"}`, opts); !errors.Is(err, push.ErrorTokenRemoved) {
+		t.Errorf("expected token removed, got %s", err)
+	}
+
+	status = http.StatusOK
+	if err := Send(srv.URL, `{"text":"hello"}`, opts); !errors.Is(err, push.ErrorServiceUnavailable) {
+		t.Errorf("expected not success status, got %s", err)
+	}
+}
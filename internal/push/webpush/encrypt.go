@@ -0,0 +1,130 @@
+package webpush
+
+/**
+ *	Implemented RFC 8291 message encryption with aes128gcm content coding (RFC 8188)
+ */
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	// record size announced in aes128gcm header, whole message is one record
+	recordSize = 4096
+	// salt(16) + rs(4) + idlen(1) + keyid(65)
+	headerSize = 86
+	// aes gcm authentication tag
+	tagSize = 16
+	// max plain text length fitted in one record with padding delimiter
+	MAX_SIZE = recordSize - headerSize - tagSize - 1
+)
+
+var (
+	ErrBadSubscription = errors.New("bad subscription keys")
+	ErrPayloadTooLarge = errors.New("payload too large")
+)
+
+// hkdf with sha256 producing no more than one hash block, enough for rfc 8291
+func hkdf(salt, ikm, info []byte, length int) []byte {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(ikm)
+	prk := mac.Sum(nil)
+
+	mac = hmac.New(sha256.New, prk)
+	mac.Write(info)
+	mac.Write([]byte{1})
+	return mac.Sum(nil)[:length]
+}
+
+// decodeKey accepts keys in url-safe or standard base64 with or without padding
+func decodeKey(key string) ([]byte, error) {
+	key = strings.TrimRight(key, "=")
+	if data, err := base64.RawURLEncoding.DecodeString(key); err == nil {
+		return data, nil
+	}
+	return base64.RawStdEncoding.DecodeString(key)
+}
+
+// encrypt encrypts plain text for subscription keys
+func encrypt(plain []byte, keys *Keys) ([]byte, error) {
+	if len(plain) > MAX_SIZE {
+		return nil, ErrPayloadTooLarge
+	}
+
+	uaPublicRaw, err := decodeKey(keys.P256dh)
+	if err != nil {
+		return nil, ErrBadSubscription
+	}
+
+	authSecret, err := decodeKey(keys.Auth)
+	if err != nil || len(authSecret) == 0 {
+		return nil, ErrBadSubscription
+	}
+
+	uaPublic, err := ecdh.P256().NewPublicKey(uaPublicRaw)
+	if err != nil {
+		return nil, ErrBadSubscription
+	}
+
+	asPrivate, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	return encryptWith(plain, uaPublic, authSecret, asPrivate, salt)
+}
+
+func encryptWith(plain []byte, uaPublic *ecdh.PublicKey, authSecret []byte, asPrivate *ecdh.PrivateKey, salt []byte) ([]byte, error) {
+	ecdhSecret, err := asPrivate.ECDH(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+
+	asPublicRaw := asPrivate.PublicKey().Bytes()
+
+	keyInfo := bytes.NewBufferString("WebPush: info\x00")
+	keyInfo.Write(uaPublic.Bytes())
+	keyInfo.Write(asPublicRaw)
+
+	ikm := hkdf(authSecret, ecdhSecret, keyInfo.Bytes(), 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// last record padding delimiter
+	record := append(append(make([]byte, 0, len(plain)+1), plain...), 2)
+
+	body := bytes.NewBuffer(make([]byte, 0, headerSize+len(record)+tagSize))
+	body.Write(salt)
+	binary.Write(body, binary.BigEndian, uint32(recordSize))
+	body.WriteByte(byte(len(asPublicRaw)))
+	body.Write(asPublicRaw)
+	body.Write(gcm.Seal(nil, nonce, record, nil))
+
+	return body.Bytes(), nil
+}
@@ -0,0 +1,36 @@
+package webpush
+
+import (
+	"encoding/json"
+
+	"push-sender/internal/push"
+)
+
+// Notification is payload shown by service worker with showNotification
+type Notification struct {
+	Title string            `json:"title,omitempty"`
+	Body  string            `json:"body,omitempty"`
+	Image string            `json:"image,omitempty"`
+	Tag   string            `json:"tag,omitempty"`
+	Data  map[string]string `json:"data,omitempty"`
+}
+
+// RenderMessage translates unified message to json payload, ttl and urgency are set to opts
+func RenderMessage(msg *push.Message, opts *WebPushOpts) string {
+	j, _ := json.Marshal(Notification{
+		Title: msg.Title,
+		Body:  msg.Body,
+		Image: msg.Image,
+		Tag:   msg.CollapseKey,
+		Data:  msg.Data,
+	})
+
+	if msg.TTL > 0 {
+		opts.TimeToLive = msg.TTL
+	}
+	if msg.IsHigh() {
+		opts.Urgency = "high"
+	}
+
+	return string(j)
+}
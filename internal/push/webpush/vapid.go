@@ -0,0 +1,95 @@
+package webpush
+
+/**
+ *	Implemented RFC 8292 VAPID authorization
+ */
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"time"
+)
+
+// vapid jwt life time, push services reject tokens valid more than 24 hours
+const vapidTTL = 12 * time.Hour
+
+type vapidToken struct {
+	Header  string
+	Expired time.Time
+}
+
+type vapidKey struct {
+	private *ecdsa.PrivateKey
+	public  string
+}
+
+// parseVapidKey makes signing key from base64url raw private scalar
+func parseVapidKey(privateKey string) (*vapidKey, error) {
+	d, err := decodeKey(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("bad vapid private key")
+	}
+
+	curve := elliptic.P256()
+	key := &ecdsa.PrivateKey{
+		D: new(big.Int).SetBytes(d),
+	}
+	key.PublicKey.Curve = curve
+	key.PublicKey.X, key.PublicKey.Y = curve.ScalarBaseMult(d)
+
+	public := make([]byte, 65)
+	public[0] = 4
+	key.PublicKey.X.FillBytes(public[1:33])
+	key.PublicKey.Y.FillBytes(public[33:])
+
+	return &vapidKey{
+		private: key,
+		public:  base64.RawURLEncoding.EncodeToString(public),
+	}, nil
+}
+
+// audience is the origin of the push service endpoint
+func audience(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("bad endpoint %s", endpoint)
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// sign makes Authorization header value for audience
+func (key *vapidKey) sign(aud string, subject string, expired time.Time) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+
+	claims, err := json.Marshal(map[string]any{
+		"aud": aud,
+		"exp": expired.Unix(),
+		"sub": subject,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hash := sha256.Sum256([]byte(unsigned))
+
+	r, s, err := ecdsa.Sign(rand.Reader, key.private, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, base64.RawURLEncoding.EncodeToString(sig), key.public), nil
+}
@@ -0,0 +1,199 @@
+package webpush
+
+/**
+ *	Implemented RFC 8030 web push messaging HTTP Proto
+ */
+
+import (
+	"bytes"
+	"crypto/tls"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
+)
+
+var transport *http.Transport
+
+func init() {
+	config.SetDefault("webpush_ttl", 24*60*60)
+	transport = &http.Transport{
+		MaxIdleConnsPerHost: 10,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     1 * time.Second,
+		TLSClientConfig:     &tls.Config{},
+	}
+}
+
+type Keys struct {
+	P256dh string `json:"p256dh"`
+	Auth   string `json:"auth"`
+}
+
+// Subscription is browser PushSubscription serialized with toJSON()
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     Keys   `json:"keys"`
+}
+
+func ParseSubscription(data string) (*Subscription, error) {
+	var sub Subscription
+	if err := json.Unmarshal([]byte(data), &sub); err != nil {
+		return nil, err
+	}
+	if !sub.Valid() {
+		return nil, ErrBadSubscription
+	}
+	return &sub, nil
+}
+
+func (sub *Subscription) Valid() bool {
+	return sub.Endpoint != "" && sub.Keys.P256dh != "" && sub.Keys.Auth != ""
+}
+
+type WebPushOpts struct {
+	ProjectId string
+	// base64url raw P-256 private key
+	VapidPrivateKey string
+	// contact of application server, mailto: or https: url
+	Subject string
+	// seconds push service keeps message for offline browser
+	TimeToLive int
+	// very-low, low, normal or high
+	Urgency string
+}
+
+func (opts *WebPushOpts) Valid() bool {
+	return opts.ProjectId != "" && opts.VapidPrivateKey != "" && opts.Subject != ""
+}
+
+type WebPushSender struct {
+	Keys   map[string]*vapidKey
+	Tokens map[string]*vapidToken
+}
+
+func New() *WebPushSender {
+	return &WebPushSender{
+		Keys:   make(map[string]*vapidKey),
+		Tokens: make(map[string]*vapidToken),
+	}
+}
+
+// GetAuthHeader returns cached vapid authorization for project and push service
+func (sender *WebPushSender) GetAuthHeader(endpoint string, opts *WebPushOpts) (string, error) {
+	aud, err := audience(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	cacheKey := opts.ProjectId + " " + aud
+
+	if token, ok := sender.Tokens[cacheKey]; ok && time.Until(token.Expired) > time.Minute {
+		return token.Header, nil
+	}
+
+	key, ok := sender.Keys[opts.ProjectId]
+	if !ok {
+		if key, err = parseVapidKey(opts.VapidPrivateKey); err != nil {
+			return "", err
+		}
+		sender.Keys[opts.ProjectId] = key
+	}
+
+	expired := time.Now().Add(vapidTTL)
+
+	header, err := key.sign(aud, opts.Subject, expired)
+	if err != nil {
+		return "", err
+	}
+
+	sender.Tokens[cacheKey] = &vapidToken{
+		Header:  header,
+		Expired: expired,
+	}
+
+	return header, nil
+}
+
+func (sender *WebPushSender) Send(sub *Subscription, data string, opts *WebPushOpts) error {
+	if opts == nil || !opts.Valid() || sub == nil || !sub.Valid() {
+		log.Errorf("newpusher webpush: opts not valid [%#v] [%#v]", opts, sub)
+		return push.ErrorRequest
+	}
+
+	body, err := encrypt([]byte(data), &sub.Keys)
+
+	if err != nil {
+		log.Errorf("newpusher webpush: cannot encrypt payload %s", err)
+		return push.ErrorRequest
+	}
+
+	auth, err := sender.GetAuthHeader(sub.Endpoint, opts)
+
+	if err != nil {
+		log.Errorf("newpusher webpush: cannot sign vapid %s", err)
+		return push.ErrorInvalidKey
+	}
+
+	request, err := http.NewRequest(http.MethodPost, sub.Endpoint, bytes.NewBuffer(body))
+
+	if err != nil {
+		log.Errorf("newpusher webpush: cannot make request %s", err)
+		return push.ErrorRequest
+	}
+
+	request.Header.Add("Authorization", auth)
+	request.Header.Add("Content-Encoding", "aes128gcm")
+	request.Header.Add("Content-Type", "application/octet-stream")
+	request.Header.Add("TTL", strconv.Itoa(opts.TimeToLive))
+	if opts.Urgency != "" {
+		request.Header.Add("Urgency", opts.Urgency)
+	}
+
+	client := http.Client{Transport: transport}
+
+	resp, err := client.Do(request)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("newpusher webpush: cannot send request %s", err)
+		return push.ErrorTransportProblem
+	}
+
+	return parseReply(resp)
+}
+
+func parseReply(resp *http.Response) error {
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound, resp.StatusCode == http.StatusGone:
+		return push.ErrorTokenRemoved
+	case resp.StatusCode == http.StatusTooManyRequests:
+		log.Errorf("newpusher webpush: ratelimit %s retry after [%s]", resp.Status, resp.Header.Get("Retry-After"))
+		return push.ErrorRateLimit
+	case resp.StatusCode == http.StatusUnauthorized, resp.StatusCode == http.StatusForbidden:
+		log.Errorf("newpusher webpush: vapid rejected %s", resp.Status)
+		return push.ErrorInvalidKey
+	case resp.StatusCode >= 500:
+		log.Errorf("newpusher webpush: ServiceUnavailable %s", resp.Status)
+		return push.ErrorServiceUnavailable
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+	log.Errorf("newpusher webpush: bad request %s %s", resp.Status, string(body))
+
+	return push.ErrorRequest
+}
@@ -0,0 +1,110 @@
+package webpush
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"push-sender/internal/push"
+)
+
+func decrypt(t *testing.T, body []byte, uaPrivate *ecdh.PrivateKey, authSecret []byte) []byte {
+	salt := body[:16]
+	idlen := int(body[20])
+	asPublic, err := ecdh.P256().NewPublicKey(body[21 : 21+idlen])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err := uaPrivate.ECDH(asPublic)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyInfo := bytes.NewBufferString("WebPush: info\x00")
+	keyInfo.Write(uaPrivate.PublicKey().Bytes())
+	keyInfo.Write(asPublic.Bytes())
+
+	ikm := hkdf(authSecret, secret, keyInfo.Bytes(), 32)
+	cek := hkdf(salt, ikm, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	nonce := hkdf(salt, ikm, []byte("Content-Encoding: nonce\x00"), 12)
+
+	block, _ := aes.NewCipher(cek)
+	gcm, _ := cipher.NewGCM(block)
+
+	plain, err := gcm.Open(nil, nonce, body[21+idlen:], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// strip padding and delimiter
+	plain = bytes.TrimRight(plain, "\x00")
+	return plain[:len(plain)-1]
+}
+
+func TestWebPush(t *testing.T) {
+	uaPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	vapidPrivate, _ := ecdh.P256().GenerateKey(rand.Reader)
+	authSecret := make([]byte, 16)
+	rand.Read(authSecret)
+
+	status := http.StatusCreated
+	var got []byte
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "vapid t=") {
+			t.Errorf("bad authorization %s", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("Content-Encoding") != "aes128gcm" {
+			t.Errorf("bad encoding %s", r.Header.Get("Content-Encoding"))
+		}
+		body, _ := io.ReadAll(r.Body)
+		got = decrypt(t, body, uaPrivate, authSecret)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sub := &Subscription{
+		Endpoint: srv.URL + "/push/xxxx",
+		Keys: Keys{
+			P256dh: base64.RawURLEncoding.EncodeToString(uaPrivate.PublicKey().Bytes()),
+			Auth:   base64.RawURLEncoding.EncodeToString(authSecret),
+		},
+	}
+
+	opts := &WebPushOpts{
+		ProjectId:       "project",
+		VapidPrivateKey: base64.RawURLEncoding.EncodeToString(vapidPrivate.Bytes()),
+		Subject:         "mailto:push@example.com",
+		TimeToLive:      60,
+	}
+
+	sender := New()
+
+	if err := sender.Send(sub, `{"title":"hello"}`, opts); err != nil {
+		t.Error(err)
+	}
+
+	if string(got) != `{"title":"hello"}` {
+		t.Errorf("bad payload %s", got)
+	}
+
+	status = http.StatusGone
+	if err := sender.Send(sub, "{}", opts); !errors.Is(err, push.ErrorTokenRemoved) {
+		t.Errorf("expected token removed, got %s", err)
+	}
+
+	status = http.StatusTooManyRequests
+	if err := sender.Send(sub, "{}", opts); !errors.Is(err, push.ErrorRateLimit) {
+		t.Errorf("expected rate limit, got %s", err)
+	}
+}
@@ -0,0 +1,116 @@
+package wns
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+type WnsTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int    `json:"expires_in"`
+	TokenType        string `json:"token_type,omitempty"`
+	Error            string `json:"error,omitempty"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type WnsToken struct {
+	AccessToken string
+	Expired     time.Time
+}
+
+func (sender *WnsSender) RefreshToken(opts *WnsMessageOpts) (string, error) {
+	postData := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {opts.ClientId},
+		"client_secret": {opts.ClientSecret},
+		"scope":         {opts.scope()},
+	}
+
+	request, err := http.NewRequest(http.MethodPost, opts.oauthApi(), bytes.NewBufferString(postData.Encode()))
+
+	if err != nil {
+		log.Errorf("newpusher wns: cannot make request %s", err)
+		return "", push.ErrorRequest
+	}
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	client := http.Client{Transport: transport}
+
+	resp, err := client.Do(request)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("newpusher wns: cannot send request %s", err)
+		return "", push.ErrorTransportProblem
+	}
+
+	body, _ := io.ReadAll(resp.Body)
+
+	if resp.StatusCode != 200 {
+		log.Errorf("newpusher wns: cannot status request %s [%s]", resp.Status, string(body))
+		return "", push.OAuthStatusError(resp.StatusCode)
+	}
+
+	var token WnsTokenResponse
+
+	err = json.Unmarshal(body, &token)
+
+	if err != nil || token.AccessToken == "" {
+		log.Errorf("newpusher wns: cannot unmarshal resp request %s", body)
+		return "", push.ErrorServiceUnavailable
+	}
+
+	sender.Tokens[opts.ClientId] = &WnsToken{
+		AccessToken: token.AccessToken,
+		Expired:     time.Now().Add(time.Duration(token.ExpiresIn) * time.Second),
+	}
+
+	return token.AccessToken, nil
+}
+
+// azure ad app registration or legacy live.com package sid
+func (opts *WnsMessageOpts) oauthApi() string {
+	if opts.TenantId != "" {
+		return fmt.Sprintf(config.GetString("wns_azure_oauth_api"), url.PathEscape(opts.TenantId))
+	}
+	return config.GetString("wns_live_oauth_api")
+}
+
+func (opts *WnsMessageOpts) scope() string {
+	if opts.TenantId != "" {
+		return "https://wns.windows.com/.default"
+	}
+	return "notify.windows.com"
+}
+
+type WnsSender struct {
+	Tokens map[string]*WnsToken
+}
+
+func New() *WnsSender {
+	return &WnsSender{
+		Tokens: make(map[string]*WnsToken),
+	}
+}
+
+func (sender *WnsSender) GetAuthToken(opts *WnsMessageOpts) (string, error) {
+	if token, ok := sender.Tokens[opts.ClientId]; ok && time.Until(token.Expired) > time.Minute {
+		return token.AccessToken, nil
+	}
+	return sender.RefreshToken(opts)
+}
@@ -0,0 +1,46 @@
+package wns
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+
+	"push-sender/internal/push"
+)
+
+// RenderMessage translates unified message to generic toast,
+// data only message is sent as raw notification
+func RenderMessage(msg *push.Message) *WnsMessage {
+	var data []byte
+	if len(msg.Data) > 0 {
+		data, _ = json.Marshal(msg.Data)
+	}
+
+	if msg.Title == "" && msg.Body == "" {
+		return &WnsMessage{Type: Raw, Payload: string(data)}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(`<toast`)
+	if data != nil {
+		buf.WriteString(` launch="`)
+		xml.EscapeText(&buf, data)
+		buf.WriteString(`"`)
+	}
+	buf.WriteString(`><visual><binding template="ToastGeneric">`)
+	for _, text := range []string{msg.Title, msg.Body} {
+		if text != "" {
+			buf.WriteString(`<text>`)
+			xml.EscapeText(&buf, []byte(text))
+			buf.WriteString(`</text>`)
+		}
+	}
+	if msg.Image != "" {
+		buf.WriteString(`<image placement="hero" src="`)
+		xml.EscapeText(&buf, []byte(msg.Image))
+		buf.WriteString(`"/>`)
+	}
+	buf.WriteString(`</binding></visual></toast>`)
+
+	return &WnsMessage{Type: Toast, Payload: buf.String(), Tag: msg.CollapseKey}
+}
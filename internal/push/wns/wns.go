@@ -0,0 +1,220 @@
+package wns
+
+/**
+ *	Implemented windows push notification service HTTP Proto
+ */
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+var transport *http.Transport
+
+// notification payload size limit
+const MAX_SIZE = 5000
+
+type NotificationType string
+
+// values of X-WNS-Type header
+const (
+	Toast NotificationType = "wns/toast"
+	Tile  NotificationType = "wns/tile"
+	Badge NotificationType = "wns/badge"
+	Raw   NotificationType = "wns/raw"
+)
+
+func init() {
+	config.SetDefault("wns_live_oauth_api", "https://login.live.com/accesstoken.srf")
+	config.SetDefault("wns_azure_oauth_api", "https://login.microsoftonline.com/%s/oauth2/v2.0/token")
+	// channel uri hosts allowed as recipients, protects our token from leaking to foreign hosts
+	config.SetDefault("wns_channel_hosts", []string{".notify.windows.com"})
+	transport = &http.Transport{
+		MaxIdleConnsPerHost: 10,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     1 * time.Second,
+		TLSClientConfig:     &tls.Config{},
+	}
+}
+
+type WnsMessage struct {
+	Type NotificationType `json:"type"`
+	// toast, tile or badge xml, or any raw data
+	Payload string `json:"payload"`
+	// X-WNS-Tag, replaces tile or toast with the same tag
+	Tag string `json:"tag,omitempty"`
+	// X-WNS-Group, toast group for the tag
+	Group string `json:"group,omitempty"`
+	// X-WNS-Cache-Policy, cache or no-cache
+	CachePolicy string `json:"cache_policy,omitempty"`
+}
+
+type WnsMessageOpts struct {
+	// azure ad application or package sid
+	ClientId     string
+	ClientSecret string
+	// azure ad tenant, empty for legacy live.com auth
+	TenantId string
+	// X-WNS-TTL, seconds
+	TimeToLive int
+}
+
+func (opts *WnsMessageOpts) Valid() bool {
+	return opts.ClientId != "" && opts.ClientSecret != ""
+}
+
+func (msg *WnsMessage) Valid() bool {
+	switch msg.Type {
+	case Toast, Tile, Badge, Raw:
+		return msg.Payload != ""
+	}
+	return false
+}
+
+func (msg *WnsMessage) contentType() string {
+	if msg.Type == Raw {
+		return "application/octet-stream"
+	}
+	return "text/xml"
+}
+
+// validChannel checks channel uri points to wns
+func validChannel(to string) bool {
+	u, err := url.Parse(to)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	for _, host := range config.GetStringSlice("wns_channel_hosts") {
+		if strings.HasSuffix(u.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (sender *WnsSender) Send(to string, msg *WnsMessage, opts *WnsMessageOpts) error {
+	if opts == nil || !opts.Valid() || msg == nil || !msg.Valid() {
+		log.Errorf("newpusher wns: opts not valid [%#v] [%#v]", opts, msg)
+		return push.ErrorRequest
+	}
+
+	if !validChannel(to) {
+		log.Errorf("newpusher wns: bad channel uri %s", to)
+		return push.ErrorRequest
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := sender.GetAuthToken(opts)
+
+		if err != nil {
+			log.Errorf("newpusher wns: get auth token %s", err)
+			// rejected credentials are permanent, oauth outage is retried
+			return err
+		}
+
+		request, err := http.NewRequest(http.MethodPost, to, bytes.NewBufferString(msg.Payload))
+
+		if err != nil {
+			log.Errorf("newpusher wns: cannot read options %s", err)
+			return push.ErrorRequest
+		}
+
+		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		request.Header.Add("Content-Type", msg.contentType())
+		request.Header.Add("X-WNS-Type", string(msg.Type))
+		if msg.Tag != "" {
+			request.Header.Add("X-WNS-Tag", msg.Tag)
+		}
+		if msg.Group != "" {
+			request.Header.Add("X-WNS-Group", msg.Group)
+		}
+		if msg.CachePolicy != "" {
+			request.Header.Add("X-WNS-Cache-Policy", msg.CachePolicy)
+		}
+		if opts.TimeToLive > 0 {
+			request.Header.Add("X-WNS-TTL", strconv.Itoa(opts.TimeToLive))
+		}
+
+		client := http.Client{Transport: transport}
+
+		resp, err := client.Do(request)
+
+		defer func() {
+			if resp != nil && resp.Body != nil {
+				resp.Body.Close()
+			}
+		}()
+
+		if err != nil {
+			log.Errorf("newpusher wns: cannot send request %s", err)
+			return push.ErrorTransportProblem
+		}
+
+		err = parseReply(resp)
+
+		if errors.Is(err, push.ErrorRefreshToken) {
+			delete(sender.Tokens, opts.ClientId)
+			continue
+		}
+
+		return err
+	}
+
+	return push.ErrorServiceUnavailable
+}
+
+func parseReply(resp *http.Response) error {
+	if resp.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	log.Errorf("newpusher wns: bad reply %s status [%s] device [%s] error [%s]",
+		resp.Status,
+		resp.Header.Get("X-WNS-Status"),
+		resp.Header.Get("X-WNS-DeviceConnectionStatus"),
+		resp.Header.Get("X-WNS-Error-Description"),
+	)
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return push.ErrorRefreshToken
+	case http.StatusForbidden:
+		return push.ErrorInvalidKey
+	case http.StatusNotFound, http.StatusGone:
+		return push.ErrorTokenRemoved
+	case http.StatusBadRequest, http.StatusMethodNotAllowed, http.StatusRequestEntityTooLarge:
+		return push.ErrorRequest
+	case http.StatusNotAcceptable:
+		return push.ErrorRateLimit
+	}
+
+	return push.ErrorServiceUnavailable
+}
+
+/**
+https://learn.microsoft.com/en-us/windows/apps/design/shell/tiles-and-notifications/push-request-response-headers
+
+200 The notification was accepted by WNS.
+400 One or more headers were specified incorrectly or conflict with another header.
+401 The cloud service did not present a valid authentication ticket, request a new token.
+403 The cloud service is not authorized to send a notification to this URI even though they are authenticated.
+404 The channel URI is not valid or is not recognized by WNS.
+405 Invalid method (GET, CREATE), only POST or DELETE is allowed.
+406 The cloud service exceeded its throttle limit.
+410 The channel expired.
+413 The notification payload exceeds the 5000 byte size limit.
+500 An internal failure caused notification delivery to fail.
+503 The server is currently unavailable.
+*/
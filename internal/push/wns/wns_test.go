@@ -0,0 +1,91 @@
+package wns
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
+)
+
+func TestWns(t *testing.T) {
+	status := http.StatusOK
+
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/accesstoken.srf" {
+			fmt.Fprint(w, `{"access_token":"token","expires_in":86400,"token_type":"bearer"}`)
+			return
+		}
+		if r.Header.Get("X-WNS-Type") != string(Toast) || r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("bad headers %v", r.Header)
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	transport = srv.Client().Transport.(*http.Transport)
+
+	config.Set("wns_live_oauth_api", srv.URL+"/accesstoken.srf")
+	config.Set("wns_channel_hosts", []string{"127.0.0.1"})
+
+	opts := &WnsMessageOpts{
+		ClientId:     "ms-app://s-1-15-2-xxx",
+		ClientSecret: "secret",
+	}
+
+	msg := &WnsMessage{
+		Type:    Toast,
+		Payload: `<toast><visual><binding template="ToastGeneric"><text>Hello</text></binding></visual></toast>`,
+	}
+
+	sender := New()
+
+	if err := sender.Send(srv.URL+"/?token=xxx", msg, opts); err != nil {
+		t.Error(err)
+	}
+
+	status = http.StatusGone
+	if err := sender.Send(srv.URL+"/?token=xxx", msg, opts); !errors.Is(err, push.ErrorTokenRemoved) {
+		t.Errorf("expected token removed, got %s", err)
+	}
+
+	// bad input must not report live channel as removed
+	if err := sender.Send("https://example.com/?token=xxx", msg, opts); !errors.Is(err, push.ErrorRequest) {
+		t.Errorf("expected foreign channel rejected as bad request, got %s", err)
+	}
+}
+
+func TestWnsOAuthOutage(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	transport = srv.Client().Transport.(*http.Transport)
+
+	config.Set("wns_live_oauth_api", srv.URL+"/accesstoken.srf")
+	config.Set("wns_channel_hosts", []string{"127.0.0.1"})
+
+	opts := &WnsMessageOpts{ClientId: "client", ClientSecret: "secret"}
+	msg := &WnsMessage{Type: Raw, Payload: "data"}
+	if err := New().Send(srv.URL+"/?token=xxx", msg, opts); err == nil || push.IsPermanent(err) {
+		t.Errorf("expected temporary error on oauth outage, got %v", err)
+	}
+}
+
+func TestRenderMessage(t *testing.T) {
+	msg := RenderMessage(&push.Message{Title: "a < b", Body: "hi", Data: map[string]string{"id": "1"}, CollapseKey: "chat"})
+	want := `<toast launch="{&#34;id&#34;:&#34;1&#34;}"><visual><binding template="ToastGeneric"><text>a &lt; b</text><text>hi</text></binding></visual></toast>`
+	if msg.Type != Toast || msg.Payload != want || msg.Tag != "chat" {
+		t.Errorf("bad toast %#v", msg)
+	}
+
+	msg = RenderMessage(&push.Message{Data: map[string]string{"id": "1"}})
+	if msg.Type != Raw || msg.Payload != `{"id":"1"}` {
+		t.Errorf("bad raw %#v", msg)
+	}
+}
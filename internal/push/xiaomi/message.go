@@ -0,0 +1,27 @@
+package xiaomi
+
+import (
+	"encoding/json"
+
+	"push-sender/internal/push"
+)
+
+// RenderMessage translates unified message to xiaomi notification, data is passed as payload
+func RenderMessage(msg *push.Message) *XiaomiMessage {
+	xmMsg := &XiaomiMessage{
+		Title:       msg.Title,
+		Description: msg.Body,
+	}
+
+	if len(msg.Data) > 0 {
+		data, _ := json.Marshal(msg.Data)
+		xmMsg.Payload = string(data)
+	}
+
+	// data only message is delivered to application without notification
+	if msg.Title == "" && msg.Body == "" {
+		xmMsg.PassThrough = 1
+	}
+
+	return xmMsg
+}
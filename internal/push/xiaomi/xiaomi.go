@@ -0,0 +1,240 @@
+package xiaomi
+
+/**
+ *	Implemented Mi Push server HTTP API v3
+ */
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
+)
+
+var transport *http.Transport
+
+const (
+	// recipient prefixes, plain recipient is a regid
+	TopicPrefix = "/topics/"
+	AliasPrefix = "/aliases/"
+
+	targetRegId = "regid"
+	targetAlias = "alias"
+	targetTopic = "topic"
+
+	MAX_SIZE = 4096
+
+	SUCCESS            = 0
+	MISSING_PARAMS     = 10016  //— отсутствует обязательный параметр.
+	INVALID_PARAMS     = 10017  //— неправильное значение параметра.
+	AUTH_FAILED        = 21301  //— неправильно указан AppSecret.
+	MISSING_PARAMS_V3  = 21305  //— отсутствует обязательный параметр.
+	INVALID_REGID      = 20301  //— неправильный regid получателя.
+	PACKAGE_MISMATCH   = 22022  //— regid получателя принадлежит другому приложению.
+	QPS_LIMIT_EXCEEDED = 22103  //— превышено количество сообщений в секунду.
+	DAILY_LIMIT        = 200002 //— превышен дневной лимит сообщений.
+)
+
+func init() {
+	config.SetDefault("xiaomi_send_api", "https://api.xmpush.xiaomi.com/v3/message/{target}")
+	transport = &http.Transport{
+		MaxIdleConnsPerHost: 10,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     1 * time.Second,
+		TLSClientConfig:     &tls.Config{},
+	}
+}
+
+// XiaomiMessage is a task payload
+type XiaomiMessage struct {
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// message content delivered to application
+	Payload string `json:"payload,omitempty"`
+	// -1 default, 1 sound, 2 vibrate, 4 lights, combined with |
+	NotifyType int `json:"notify_type,omitempty"`
+	// 0 notification bar message, 1 pass-through message
+	PassThrough int `json:"pass_through,omitempty"`
+	// notifications with the same id replace each other
+	NotifyId int `json:"notify_id,omitempty"`
+	// extra.* fields, e.g. sound_uri, notify_effect, channel_id
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+type XiaomiMessageOpts struct {
+	AppSecret   string
+	PackageName string
+	// seconds
+	TimeToLive int
+}
+
+func (opts *XiaomiMessageOpts) Valid() bool {
+	return opts.AppSecret != "" && opts.PackageName != ""
+}
+
+type XiaomiResponse struct {
+	Result      string `json:"result"`
+	Code        int    `json:"code"`
+	Description string `json:"description"`
+	Reason      string `json:"reason"`
+	TraceId     string `json:"trace_id"`
+	Data        struct {
+		Id        string `json:"id"`
+		BadRegIds string `json:"bad_regids"`
+	} `json:"data"`
+}
+
+func ParseMessage(data string) (*XiaomiMessage, error) {
+	var msg XiaomiMessage
+	if err := json.Unmarshal([]byte(data), &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+func target(to string) (string, string) {
+	if strings.HasPrefix(to, TopicPrefix) {
+		return targetTopic, strings.TrimPrefix(to, TopicPrefix)
+	}
+	if strings.HasPrefix(to, AliasPrefix) {
+		return targetAlias, strings.TrimPrefix(to, AliasPrefix)
+	}
+	return targetRegId, to
+}
+
+func makeForm(to string, msg *XiaomiMessage, opts *XiaomiMessageOpts) (string, url.Values) {
+	kind, recipient := target(to)
+
+	form := url.Values{}
+
+	switch kind {
+	case targetTopic:
+		form.Set("topic", recipient)
+	case targetAlias:
+		form.Set("alias", recipient)
+	default:
+		form.Set("registration_id", recipient)
+	}
+
+	form.Set("restricted_package_name", opts.PackageName)
+	form.Set("pass_through", strconv.Itoa(msg.PassThrough))
+	form.Set("payload", msg.Payload)
+
+	if msg.Title != "" {
+		form.Set("title", msg.Title)
+	}
+	if msg.Description != "" {
+		form.Set("description", msg.Description)
+	}
+	if msg.NotifyType != 0 {
+		form.Set("notify_type", strconv.Itoa(msg.NotifyType))
+	}
+	if msg.NotifyId != 0 {
+		form.Set("notify_id", strconv.Itoa(msg.NotifyId))
+	}
+	if opts.TimeToLive > 0 {
+		form.Set("time_to_live", strconv.Itoa(opts.TimeToLive*1000))
+	}
+	for k, v := range msg.Extra {
+		form.Set("extra."+k, v)
+	}
+
+	return kind, form
+}
+
+func parseReply(body []byte) error {
+	var resp XiaomiResponse
+
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Errorf("newpusher xiaomi: parse reply error %s %s", err, string(body))
+		return push.ErrorServiceUnavailable
+	}
+
+	if resp.Code == SUCCESS && resp.Data.BadRegIds == "" {
+		return nil
+	}
+
+	log.Errorf("newpusher xiaomi: bad reply %#v", resp)
+
+	switch resp.Code {
+	case SUCCESS, INVALID_REGID, PACKAGE_MISMATCH:
+		return push.ErrorTokenRemoved
+	case AUTH_FAILED:
+		return push.ErrorInvalidKey
+	case MISSING_PARAMS, MISSING_PARAMS_V3, INVALID_PARAMS:
+		return push.ErrorRequest
+	case QPS_LIMIT_EXCEEDED, DAILY_LIMIT:
+		return push.ErrorRateLimit
+	}
+
+	return fmt.Errorf("xiaomi: error [%w]", push.PushError(strconv.Itoa(resp.Code)))
+}
+
+func Send(to string, msg *XiaomiMessage, opts *XiaomiMessageOpts) error {
+	if opts == nil || !opts.Valid() || msg == nil || to == "" {
+		log.Errorf("newpusher xiaomi: opts not valid [%#v] [%s]", opts, to)
+		return push.ErrorRequest
+	}
+
+	kind, form := makeForm(to, msg, opts)
+
+	mUrl := strings.ReplaceAll(config.GetString("xiaomi_send_api"), "{target}", kind)
+
+	request, err := http.NewRequest(http.MethodPost, mUrl, bytes.NewBufferString(form.Encode()))
+
+	if err != nil {
+		log.Errorf("newpusher xiaomi: cannot make request %s", err)
+		return push.ErrorRequest
+	}
+
+	request.Header.Add("Authorization", fmt.Sprintf("key=%s", opts.AppSecret))
+	request.Header.Add("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+
+	client := http.Client{Transport: transport}
+
+	resp, err := client.Do(request)
+
+	defer func() {
+		if resp != nil && resp.Body != nil {
+			resp.Body.Close()
+		}
+	}()
+
+	if err != nil {
+		log.Errorf("newpusher xiaomi: cannot send request %s", err)
+		return push.ErrorTransportProblem
+	}
+
+	body, err := io.ReadAll(resp.Body)
+
+	if err != nil {
+		log.Errorf("newpusher xiaomi: cannot read data %s", err)
+		return push.ErrorServiceUnavailable
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		log.Errorf("newpusher xiaomi: auth failed %s %s", resp.Status, string(body))
+		return push.ErrorInvalidKey
+	case http.StatusTooManyRequests:
+		log.Errorf("newpusher xiaomi: ratelimit %s %s", resp.Status, string(body))
+		return push.ErrorRateLimit
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		log.Errorf("newpusher xiaomi: ServiceUnavailable %s %s", resp.Status, string(body))
+		return push.ErrorServiceUnavailable
+	}
+
+	return parseReply(body)
+}
@@ -0,0 +1,63 @@
+package xiaomi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/push"
+)
+
+func TestXiaomi(t *testing.T) {
+	reply := `{"result":"ok","code":0,"data":{"id":"xxx"}}`
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "key=secret" {
+			t.Errorf("bad authorization %s", r.Header.Get("Authorization"))
+		}
+		r.ParseForm()
+		if r.URL.Path == "/v3/message/topic" && r.PostForm.Get("topic") != "news" {
+			t.Errorf("bad topic %v", r.PostForm)
+		}
+		if r.PostForm.Get("extra.channel_id") != "chat" {
+			t.Errorf("bad extra %v", r.PostForm)
+		}
+		fmt.Fprint(w, reply)
+	}))
+	defer srv.Close()
+
+	config.Set("xiaomi_send_api", srv.URL+"/v3/message/{target}")
+
+	opts := &XiaomiMessageOpts{
+		AppSecret:   "secret",
+		PackageName: "ru.mail.test",
+	}
+
+	msg := &XiaomiMessage{
+		Title:       "title",
+		Description: "body",
+		Extra:       map[string]string{"channel_id": "chat"},
+	}
+
+	if err := Send("regid", msg, opts); err != nil {
+		t.Error(err)
+	}
+
+	if err := Send(TopicPrefix+"news", msg, opts); err != nil {
+		t.Error(err)
+	}
+
+	reply = `{"result":"error","code":21301,"description":"auth failed"}`
+	if err := Send("regid", msg, opts); !errors.Is(err, push.ErrorInvalidKey) {
+		t.Errorf("expected invalid key, got %s", err)
+	}
+
+	reply = `{"result":"ok","code":0,"data":{"bad_regids":"regid"}}`
+	if err := Send("regid", msg, opts); !errors.Is(err, push.ErrorTokenRemoved) {
+		t.Errorf("expected token removed, got %s", err)
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"time"
+
+	"push-sender/internal/tnt"
+
+	tarantool "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+// counter counts sends of key in window shared by all sender instances
+type counter interface {
+	Incr(key string, window time.Time, ttl time.Duration) (int64, error)
+	Decr(key string, window time.Time) error
+}
+
+// incrExpr increments window counter and returns it, memtx does not yield
+// between upsert and get so the pair is atomic. Space format:
+// [key string, window unsigned, count integer, expires_at unsigned],
+// primary index {key, window}, expires_at is for expirationd cleanup.
+const incrExpr = `
+local space, key, window, expires = ...
+box.space[space]:upsert({key, window, 1, expires}, {{'+', 3, 1}})
+return box.space[space]:get{key, window}[3]
+`
+
+const decrExpr = `
+local space, key, window = ...
+box.space[space]:update({key, window}, {{'-', 3, 1}})
+`
+
+type tntCounter struct {
+	space string
+	pool  *tnt.LazyPool
+}
+
+func newTntCounter(space string) *tntCounter {
+	return &tntCounter{
+		space: space,
+		pool:  tnt.DefaultPool(),
+	}
+}
+
+func (c *tntCounter) Incr(key string, window time.Time, ttl time.Duration) (int64, error) {
+	// sends are not held while tarantool connects, local buckets are used meanwhile
+	connPool, err := c.pool.Current()
+	if err != nil {
+		return 0, err
+	}
+
+	var count []int64
+	err = connPool.Do(
+		tarantool.NewEvalRequest(incrExpr).Args([]any{c.space, key, window.Unix(), window.Add(ttl).Unix()}),
+		pool.RW,
+	).GetTyped(&count)
+
+	if err != nil {
+		return 0, err
+	}
+	if len(count) == 0 {
+		return 0, ErrBadReply
+	}
+	return count[0], nil
+}
+
+func (c *tntCounter) Decr(key string, window time.Time) error {
+	connPool, err := c.pool.Current()
+	if err != nil {
+		return err
+	}
+
+	_, err = connPool.Do(
+		tarantool.NewEvalRequest(decrExpr).Args([]any{c.space, key, window.Unix()}),
+		pool.RW,
+	).Get()
+	return err
+}
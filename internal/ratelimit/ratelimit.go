@@ -0,0 +1,196 @@
+package ratelimit
+
+/**
+ *	Token bucket limits applied before sending, configured as
+ *
+ *	ratelimit:
+ *	  global:
+ *	    rate: 1000     # tokens per second
+ *	    burst: 2000    # bucket size, rate by default
+ *	  platforms:
+ *	    huawei:
+ *	      rate: 100
+ *	  projects:
+ *	    shop:
+ *	      rate: 50
+ *
+ *	With distributed: true limits are shared by all instances through fixed
+ *	window counters in tarantool space, local buckets are used while it is
+ *	unreachable.
+ */
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+var ErrBadReply = errors.New("bad counter reply")
+
+func init() {
+	config.SetDefault("ratelimit.distributed", false)
+	config.SetDefault("ratelimit.space", "push_ratelimit")
+	config.SetDefault("ratelimit.window", time.Second)
+}
+
+// Bucket is refilled with rate tokens per second up to burst
+type Bucket struct {
+	mutex  sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func NewBucket(rate float64, burst int) *Bucket {
+	return &Bucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Take takes one token or returns time until it is available
+func (b *Bucket) Take(now time.Time) (time.Duration, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second)), false
+}
+
+// Refund returns token taken for send that did not happen
+func (b *Bucket) Refund() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+type Limiter struct {
+	mutex sync.Mutex
+	// nil bucket means key is not limited
+	buckets map[string]*Bucket
+
+	// shared counters, nil for local limits only
+	shared   counter
+	window   time.Duration
+	fallback atomic.Bool
+}
+
+func NewLimiter() *Limiter {
+	return &Limiter{
+		buckets: make(map[string]*Bucket),
+	}
+}
+
+var (
+	defaultLimiter *Limiter
+	defaultOnce    sync.Once
+)
+
+func Default() *Limiter {
+	defaultOnce.Do(func() {
+		defaultLimiter = NewLimiter()
+		if config.GetBool("ratelimit.distributed") {
+			defaultLimiter.shared = newTntCounter(config.GetString("ratelimit.space"))
+			defaultLimiter.window = config.GetDuration("ratelimit.window")
+		}
+	})
+	return defaultLimiter
+}
+
+// Reload drops buckets to pick new limits, used as runner config change callback
+func Reload(_ string) {
+	l := Default()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.buckets = make(map[string]*Bucket)
+	log.Info("ratelimit: reloaded")
+}
+
+func (l *Limiter) bucket(key string) *Bucket {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		rate := config.GetFloat64("ratelimit." + key + ".rate")
+		if rate > 0 {
+			burst := config.GetInt("ratelimit." + key + ".burst")
+			if burst <= 0 {
+				burst = int(math.Max(1, math.Ceil(rate)))
+			}
+			b = NewBucket(rate, burst)
+		}
+		l.buckets[key] = b
+	}
+	return b
+}
+
+// Allow takes token from global, platform and project buckets,
+// returns wait of the first empty one
+func (l *Limiter) Allow(project string, platform task.Platform) (time.Duration, bool) {
+	now := time.Now()
+	taken := make([]func(), 0, 3)
+
+	for _, key := range []string{"global", "platforms." + string(platform), "projects." + project} {
+		b := l.bucket(key)
+		if b == nil {
+			continue
+		}
+		wait, ok, refund := l.take(key, b, now)
+		if !ok {
+			for _, r := range taken {
+				r()
+			}
+			return wait, false
+		}
+		taken = append(taken, refund)
+	}
+
+	return 0, true
+}
+
+// take uses shared window counter when available, local bucket otherwise
+func (l *Limiter) take(key string, b *Bucket, now time.Time) (time.Duration, bool, func()) {
+	if l.shared != nil && l.window > 0 {
+		window := now.Truncate(l.window)
+		count, err := l.shared.Incr(key, window, 2*l.window)
+		if err == nil {
+			if l.fallback.CompareAndSwap(true, false) {
+				log.Info("ratelimit: distributed limits restored")
+			}
+			if float64(count) <= math.Max(1, math.Round(b.rate*l.window.Seconds())) {
+				return 0, true, func() {
+					if err := l.shared.Decr(key, window); err != nil {
+						log.Errorf("ratelimit: cannot refund %s %s", key, err)
+					}
+				}
+			}
+			return window.Add(l.window).Sub(now), false, nil
+		}
+		if l.fallback.CompareAndSwap(false, true) {
+			log.Errorf("ratelimit: distributed limits unavailable, local fallback %s", err)
+		}
+	}
+
+	wait, ok := b.Take(now)
+	return wait, ok, b.Refund
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"push-sender/internal/task"
+
+	config "github.com/spf13/viper"
+)
+
+func TestBucket(t *testing.T) {
+	b := NewBucket(10, 2)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if _, ok := b.Take(now); !ok {
+			t.Fatalf("burst token %d not taken", i)
+		}
+	}
+
+	wait, ok := b.Take(now)
+	if ok || wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("bad wait %s %v", wait, ok)
+	}
+
+	if _, ok := b.Take(now.Add(100 * time.Millisecond)); !ok {
+		t.Fatalf("token not refilled")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	config.Set("ratelimit.platforms.huawei.rate", 2)
+	config.Set("ratelimit.projects.shop.rate", 1)
+	defer config.Set("ratelimit.platforms.huawei.rate", 0)
+	defer config.Set("ratelimit.projects.shop.rate", 0)
+
+	l := NewLimiter()
+
+	if _, ok := l.Allow("shop", task.Huawei); !ok {
+		t.Fatalf("first send throttled")
+	}
+	if _, ok := l.Allow("shop", task.Huawei); ok {
+		t.Fatalf("project limit not applied")
+	}
+
+	// huawei token taken before project refusal is refunded
+	if _, ok := l.Allow("other", task.Huawei); !ok {
+		t.Fatalf("platform token not refunded")
+	}
+	if _, ok := l.Allow("other", task.Huawei); ok {
+		t.Fatalf("platform limit not applied")
+	}
+
+	if _, ok := l.Allow("other", task.Android); !ok {
+		t.Fatalf("unlimited platform throttled")
+	}
+}
+
+type fakeCounter struct {
+	down   bool
+	counts map[string]int64
+}
+
+func (c *fakeCounter) Incr(key string, window time.Time, ttl time.Duration) (int64, error) {
+	if c.down {
+		return 0, ErrBadReply
+	}
+	c.counts[key]++
+	return c.counts[key], nil
+}
+
+func (c *fakeCounter) Decr(key string, window time.Time) error {
+	c.counts[key]--
+	return nil
+}
+
+func TestDistributed(t *testing.T) {
+	config.Set("ratelimit.platforms.huawei.rate", 2)
+	config.Set("ratelimit.projects.shop.rate", 1)
+	defer config.Set("ratelimit.platforms.huawei.rate", 0)
+	defer config.Set("ratelimit.projects.shop.rate", 0)
+
+	shared := &fakeCounter{counts: make(map[string]int64)}
+	l := NewLimiter()
+	l.shared = shared
+	l.window = time.Second
+
+	// another instance already used the platform window
+	shared.counts["platforms.huawei"] = 1
+
+	if _, ok := l.Allow("shop", task.Huawei); !ok {
+		t.Fatalf("first send throttled")
+	}
+	wait, ok := l.Allow("other", task.Huawei)
+	if ok || wait <= 0 || wait > time.Second {
+		t.Fatalf("shared limit not applied %s", wait)
+	}
+
+	shared.down = true
+	for i := 0; i < 2; i++ {
+		if _, ok := l.Allow("other", task.Huawei); !ok {
+			t.Fatalf("local fallback throttled %d", i)
+		}
+	}
+	if _, ok := l.Allow("other", task.Huawei); ok {
+		t.Fatalf("local fallback not limited")
+	}
+}
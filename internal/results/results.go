@@ -0,0 +1,101 @@
+package results
+
+/**
+ *	Per task delivery outcome reported back to the producer side
+ */
+
+import (
+	"time"
+
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+func init() {
+	config.SetDefault("results.sink", "")
+}
+
+type Result struct {
+	TaskId uint64 `json:"task_id"`
+	// task ids are unique within one lane queue, so lane is a part of result key
+	Lane     string        `json:"lane"`
+	Project  string        `json:"project"`
+	Platform task.Platform `json:"platform"`
+	To       string        `json:"to"`
+	// provider message id: fcm name, apns-id, hms requestId
+	MessageId string `json:"message_id,omitempty"`
+	// final push error code, empty when delivered
+	Code string `json:"code,omitempty"`
+	// sends made by the final take of task over its fallback chain, earlier
+	// takes released with retry error are not counted
+	Sends      int       `json:"sends"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// NewResult makes outcome of task processing started at startedAt
+func NewResult(qtask *task.Task, sends int, err error, startedAt time.Time) *Result {
+	target := task.Target{Type: qtask.Type, To: qtask.To}
+	if qtask.Delivered != nil {
+		target = *qtask.Delivered
+	}
+
+	return &Result{
+		TaskId:     qtask.ID,
+		Lane:       qtask.Lane,
+		Project:    qtask.Project,
+		Platform:   target.Type,
+		To:         target.To,
+		MessageId:  qtask.MessageId,
+		Code:       push.ErrorCode(err),
+		Sends:      sends,
+		StartedAt:  startedAt,
+		FinishedAt: time.Now(),
+	}
+}
+
+// Tuple returns tarantool representation keyed by {task_id, lane},
+// timestamps in unix milliseconds
+func (r *Result) Tuple() []any {
+	return []any{
+		r.TaskId,
+		r.Lane,
+		r.Project,
+		string(r.Platform),
+		r.To,
+		r.MessageId,
+		r.Code,
+		r.Sends,
+		r.StartedAt.UnixMilli(),
+		r.FinishedAt.UnixMilli(),
+	}
+}
+
+type Sink interface {
+	Put(result *Result)
+	// Close flushes buffered results, Put must not be called after
+	Close()
+}
+
+type noopSink struct{}
+
+func (noopSink) Put(result *Result) {
+	log.Debugf("results: task %d code [%s] sends %d", result.TaskId, result.Code, result.Sends)
+}
+
+func (noopSink) Close() {}
+
+func NewDefaultSink() Sink {
+	switch config.GetString("results.sink") {
+	case "tarantool":
+		return NewTntSink()
+	case "":
+		return noopSink{}
+	default:
+		log.Errorf("results: unknown sink %s, results are not stored", config.GetString("results.sink"))
+		return noopSink{}
+	}
+}
@@ -0,0 +1,145 @@
+package results
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"push-sender/internal/push"
+	"push-sender/internal/spool"
+	"push-sender/internal/task"
+
+	config "github.com/spf13/viper"
+)
+
+type fakeStorage struct {
+	mutex   sync.Mutex
+	down    bool
+	batches [][]*Result
+	// goes down after given number of written batches when positive
+	downAfter int
+}
+
+func (f *fakeStorage) Write(batch []*Result) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.down || (f.downAfter > 0 && len(f.batches) >= f.downAfter) {
+		return errors.New("no rw instance")
+	}
+	f.batches = append(f.batches, append([]*Result(nil), batch...))
+	return nil
+}
+
+func (f *fakeStorage) ids() []uint64 {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	var ids []uint64
+	for _, b := range f.batches {
+		for _, r := range b {
+			ids = append(ids, r.TaskId)
+		}
+	}
+	return ids
+}
+
+func TestNewResult(t *testing.T) {
+	qtask := &task.Task{ID: 7, Project: "p", Type: task.Android, To: "tok"}
+	qtask.Delivered = &task.Target{Type: task.Rustore, To: "tok2"}
+	qtask.MessageId = "projects/p/messages/1"
+
+	r := NewResult(qtask, 2, nil, time.Now())
+	if r.Platform != task.Rustore || r.To != "tok2" || r.Code != "" || r.Sends != 2 {
+		t.Fatalf("bad result %#v", r)
+	}
+
+	r = NewResult(&task.Task{ID: 8, Type: task.Ios, To: "t"}, 1, push.ErrorTokenRemoved, time.Now())
+	if r.Platform != task.Ios || r.Code != "TokenRemoved.STATUS_URL.ACTION_REMOVE" {
+		t.Fatalf("bad result %#v", r)
+	}
+
+	r = NewResult(&task.Task{ID: 8, Lane: "otp", Type: task.Ios, To: "t"}, 1, nil, time.Now())
+	if tuple := r.Tuple(); len(tuple) != 10 || tuple[0] != uint64(8) || tuple[1] != "otp" {
+		t.Fatalf("bad tuple %v", tuple)
+	}
+}
+
+func TestSinkSpool(t *testing.T) {
+	config.Set("results.batch_size", 2)
+	config.Set("results.flush_interval", time.Hour)
+	defer config.Set("results.batch_size", 100)
+	defer config.Set("results.flush_interval", time.Second)
+
+	storage := &fakeStorage{down: true}
+	sp := spool.New[Result](filepath.Join(t.TempDir(), "results.spool"))
+
+	sink := newTntSink(storage.Write, sp)
+	for i := 1; i <= 3; i++ {
+		sink.Put(&Result{TaskId: uint64(i)})
+	}
+	sink.Close()
+
+	if len(storage.ids()) != 0 || !sp.Exists() {
+		t.Fatalf("results not spooled")
+	}
+
+	storage.down = false
+
+	sink = newTntSink(storage.Write, sp)
+	sink.Put(&Result{TaskId: 4})
+	sink.Close()
+
+	ids := storage.ids()
+	if len(ids) != 4 {
+		t.Fatalf("bad written %v", ids)
+	}
+	for i, id := range ids {
+		if id != uint64(i+1) {
+			t.Fatalf("order broken %v", ids)
+		}
+	}
+	if sp.Exists() {
+		t.Fatalf("spool not removed")
+	}
+}
+
+// partially replayed spool keeps only unwritten results, queue puts are not idempotent
+func TestSinkPartialReplay(t *testing.T) {
+	config.Set("results.batch_size", 2)
+	config.Set("results.flush_interval", time.Hour)
+	defer config.Set("results.batch_size", 100)
+	defer config.Set("results.flush_interval", time.Second)
+
+	sp := spool.New[Result](filepath.Join(t.TempDir(), "results.spool"))
+	spooled := make([]*Result, 0, 5)
+	for i := 1; i <= 5; i++ {
+		spooled = append(spooled, &Result{TaskId: uint64(i)})
+	}
+	if err := sp.Append(spooled); err != nil {
+		t.Fatal(err)
+	}
+
+	storage := &fakeStorage{downAfter: 1}
+	sink := newTntSink(storage.Write, sp)
+	sink.Close()
+
+	left, err := sp.Load()
+	if err != nil || len(left) != 3 || left[0].TaskId != 3 {
+		t.Fatalf("bad spool after partial replay %v %v", left, err)
+	}
+
+	storage.downAfter = 0
+	sink = newTntSink(storage.Write, sp)
+	sink.Close()
+
+	ids := storage.ids()
+	if len(ids) != 5 {
+		t.Fatalf("results written twice or lost %v", ids)
+	}
+	for i, id := range ids {
+		if id != uint64(i+1) {
+			t.Fatalf("order broken %v", ids)
+		}
+	}
+}
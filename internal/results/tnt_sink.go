@@ -0,0 +1,209 @@
+package results
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"push-sender/internal/spool"
+	"push-sender/internal/tnt"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+	tarantool "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+	"github.com/tarantool/go-tarantool/v2/queue"
+)
+
+const (
+	ModeSpace = "space"
+	ModeQueue = "queue"
+)
+
+func init() {
+	config.SetDefault("results.mode", ModeSpace)
+	config.SetDefault("results.space", "push_results")
+	config.SetDefault("results.queue", "push_results")
+	config.SetDefault("results.batch_size", 100)
+	config.SetDefault("results.flush_interval", time.Second)
+	config.SetDefault("results.buffer", 1000)
+	config.SetDefault("results.spool_file", filepath.Join(os.TempDir(), "push-sender-results.spool"))
+}
+
+// tntSink batches results and writes them to tarantool space or queue,
+// batches failed to write are spooled to local file and replayed later
+type tntSink struct {
+	results       chan *Result
+	done          chan struct{}
+	write         func(batch []*Result) error
+	spool         *spool.Spool[Result]
+	batchSize     int
+	flushInterval time.Duration
+}
+
+func NewTntSink() Sink {
+	w := &tntWriter{
+		mode:  config.GetString("results.mode"),
+		space: config.GetString("results.space"),
+		queue: config.GetString("results.queue"),
+		pool:  tnt.DefaultPool(),
+	}
+
+	return newTntSink(w.Write, spool.New[Result](config.GetString("results.spool_file")))
+}
+
+func newTntSink(write func(batch []*Result) error, sp *spool.Spool[Result]) *tntSink {
+	s := &tntSink{
+		results:       make(chan *Result, config.GetInt("results.buffer")),
+		done:          make(chan struct{}),
+		write:         write,
+		spool:         sp,
+		batchSize:     config.GetInt("results.batch_size"),
+		flushInterval: config.GetDuration("results.flush_interval"),
+	}
+
+	if s.batchSize <= 0 {
+		s.batchSize = 1
+	}
+	if s.flushInterval <= 0 {
+		s.flushInterval = time.Second
+	}
+
+	go s.run()
+
+	return s
+}
+
+func (s *tntSink) Put(result *Result) {
+	s.results <- result
+}
+
+func (s *tntSink) Close() {
+	close(s.results)
+	<-s.done
+}
+
+func (s *tntSink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Result, 0, s.batchSize)
+
+	for {
+		select {
+		case r, ok := <-s.results:
+			if !ok {
+				s.flush(batch)
+				return
+			}
+			batch = append(batch, r)
+			if len(batch) >= s.batchSize {
+				s.flush(batch)
+				batch = make([]*Result, 0, s.batchSize)
+			}
+		case <-ticker.C:
+			s.flush(batch)
+			batch = make([]*Result, 0, s.batchSize)
+		}
+	}
+}
+
+// flush replays spool first so results keep their order
+func (s *tntSink) flush(batch []*Result) {
+	if s.spool.Exists() {
+		if !s.replay() {
+			s.toSpool(batch)
+			return
+		}
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	if err := s.write(batch); err != nil {
+		log.Errorf("results: cannot write %d results %s", len(batch), err)
+		s.toSpool(batch)
+	}
+}
+
+func (s *tntSink) replay() bool {
+	spooled, err := s.spool.Load()
+	if err != nil {
+		log.Errorf("results: cannot read spool %s", err)
+		return false
+	}
+
+	for start := 0; start < len(spooled); start += s.batchSize {
+		end := start + s.batchSize
+		if end > len(spooled) {
+			end = len(spooled)
+		}
+		if err := s.write(spooled[start:end]); err != nil {
+			// written batches are trimmed, so queue mode does not put them twice
+			if start > 0 {
+				if err := s.spool.Replace(spooled[start:]); err != nil {
+					log.Errorf("results: cannot rewrite spool %s", err)
+				}
+			}
+			return false
+		}
+	}
+
+	if err := s.spool.Remove(); err != nil {
+		log.Errorf("results: cannot remove spool %s", err)
+		return false
+	}
+
+	log.Infof("results: replayed %d spooled results", len(spooled))
+	return true
+}
+
+func (s *tntSink) toSpool(batch []*Result) {
+	if len(batch) == 0 {
+		return
+	}
+	if err := s.spool.Append(batch); err != nil {
+		log.Errorf("results: cannot spool %d results, lost %s", len(batch), err)
+	}
+}
+
+type tntWriter struct {
+	mode  string
+	space string
+	queue string
+	pool  *tnt.LazyPool
+}
+
+func (w *tntWriter) Write(batch []*Result) error {
+	connPool, err := w.pool.Get()
+	if err != nil {
+		return err
+	}
+
+	if w.mode == ModeQueue {
+		q := queue.New(pool.NewConnectorAdapter(connPool, pool.RW), w.queue)
+		for _, r := range batch {
+			if _, err := q.Put(r.Tuple()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// space primary key is {task_id, lane}, see Result.Tuple
+	futures := make([]*tarantool.Future, 0, len(batch))
+	for _, r := range batch {
+		futures = append(futures, connPool.Do(tarantool.NewReplaceRequest(w.space).Tuple(r.Tuple()), pool.RW))
+	}
+
+	for _, fut := range futures {
+		if _, e := fut.Get(); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
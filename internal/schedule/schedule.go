@@ -0,0 +1,172 @@
+package schedule
+
+/**
+ *	Scheduled delivery: task is deferred until its send_at and out of
+ *	recipient quiet hours. Deferred task is released back to the queue with
+ *	delay, so no worker is held while waiting.
+ *
+ *	schedule:
+ *	  timezone: Europe/Moscow
+ *	  quiet_hours: "22:00-08:00"
+ *	  lanes: [marketing]
+ *	  projects: [shop]
+ *
+ *	Configured quiet hours apply only to listed lanes and projects and never
+ *	to high priority lanes. Task timezone and quiet hours override configured ones.
+ */
+
+import (
+	"fmt"
+	"push-sender/internal/containers/slices"
+	"push-sender/internal/lanes"
+	"push-sender/internal/task"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+func init() {
+	// recipient timezone when task has none
+	config.SetDefault("schedule.timezone", "UTC")
+	// quiet hours window when task has none, empty disables
+	config.SetDefault("schedule.quiet_hours", "")
+	// lanes and projects opted in to configured quiet hours
+	config.SetDefault("schedule.lanes", []string{})
+	config.SetDefault("schedule.projects", []string{})
+	// shorter deferrals are sent at once
+	config.SetDefault("schedule.min_delay", time.Second)
+}
+
+// Window is daily local time span, it wraps midnight when From is after To
+type Window struct {
+	From time.Duration
+	To   time.Duration
+}
+
+// ParseWindow parses "HH:MM-HH:MM"
+func ParseWindow(s string) (Window, error) {
+	from, to, ok := strings.Cut(strings.TrimSpace(s), "-")
+	if !ok {
+		return Window{}, fmt.Errorf("bad quiet hours %q", s)
+	}
+	var w Window
+	var err error
+	if w.From, err = parseClock(from); err != nil {
+		return Window{}, err
+	}
+	if w.To, err = parseClock(to); err != nil {
+		return Window{}, err
+	}
+	return w, nil
+}
+
+func parseClock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, fmt.Errorf("bad clock %q", s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// End returns the end of window containing local time t, ok is false outside window
+func (w Window) End(t time.Time) (time.Time, bool) {
+	if w.From == w.To {
+		return t, false
+	}
+
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	// wall clock is used, so DST shifts do not move window bounds
+	at := func(day int, d time.Duration) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day()+day,
+			int(d/time.Hour), int(d%time.Hour/time.Minute), 0, 0, t.Location())
+	}
+
+	if w.From < w.To {
+		if clock >= w.From && clock < w.To {
+			return at(0, w.To), true
+		}
+		return t, false
+	}
+
+	switch {
+	case clock >= w.From:
+		return at(1, w.To), true
+	case clock < w.To:
+		return at(0, w.To), true
+	}
+	return t, false
+}
+
+var locations sync.Map
+
+func location(name string) *time.Location {
+	if name == "" {
+		name = config.GetString("schedule.timezone")
+	}
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		log.Warnf("schedule: unknown timezone %s, use UTC", name)
+		loc = time.UTC
+	}
+	locations.Store(name, loc)
+	return loc
+}
+
+// defaultQuietHours returns configured window if task lane or project opted in,
+// urgent tasks of high lanes are never held
+func defaultQuietHours(qtask *task.Task) string {
+	if lanes.IsHigh(qtask.Lane) {
+		return ""
+	}
+	if slices.Contains(config.GetStringSlice("schedule.lanes"), qtask.Lane) ||
+		slices.Contains(config.GetStringSlice("schedule.projects"), qtask.Project) {
+		return config.GetString("schedule.quiet_hours")
+	}
+	return ""
+}
+
+// Next returns the earliest time task may be sent at, not before now
+func Next(qtask *task.Task, now time.Time) time.Time {
+	at := now
+	if qtask.SendAt > 0 {
+		if sendAt := time.Unix(qtask.SendAt, 0); sendAt.After(at) {
+			at = sendAt
+		}
+	}
+
+	quiet := qtask.QuietHours
+	if quiet == "" {
+		quiet = defaultQuietHours(qtask)
+	}
+	if quiet == "" {
+		return at
+	}
+
+	w, err := ParseWindow(quiet)
+	if err != nil {
+		log.Warnf("schedule: task %d %s", qtask.ID, err)
+		return at
+	}
+
+	if end, ok := w.End(at.In(location(qtask.Timezone))); ok {
+		return end
+	}
+	return at
+}
+
+// Delay returns how long task must wait, zero when it is due
+func Delay(qtask *task.Task, now time.Time) time.Duration {
+	delay := Next(qtask, now).Sub(now)
+	if delay < config.GetDuration("schedule.min_delay") {
+		return 0
+	}
+	return delay
+}
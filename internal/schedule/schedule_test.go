@@ -0,0 +1,84 @@
+package schedule
+
+import (
+	"push-sender/internal/task"
+	"testing"
+	"time"
+
+	config "github.com/spf13/viper"
+)
+
+func TestWindow(t *testing.T) {
+	w, err := ParseWindow("22:00-08:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	day := func(d int, h int, m int) time.Time {
+		return time.Date(2024, 3, d, h, m, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		at    time.Time
+		end   time.Time
+		quiet bool
+	}{
+		{day(10, 12, 0), day(10, 12, 0), false},
+		{day(10, 23, 30), day(11, 8, 0), true},
+		{day(10, 3, 0), day(10, 8, 0), true},
+		{day(10, 8, 0), day(10, 8, 0), false},
+	}
+	for _, c := range cases {
+		end, ok := w.End(c.at)
+		if ok != c.quiet || !end.Equal(c.end) {
+			t.Errorf("%s: got %s %v", c.at, end, ok)
+		}
+	}
+
+	if _, err := ParseWindow("22-08"); err == nil {
+		t.Error("bad window parsed")
+	}
+}
+
+func TestNext(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	if at := Next(&task.Task{}, now); !at.Equal(now) {
+		t.Errorf("immediate task deferred to %s", at)
+	}
+
+	later := now.Add(time.Hour)
+	if at := Next(&task.Task{SendAt: later.Unix()}, now); !at.Equal(later) {
+		t.Errorf("send_at ignored %s", at)
+	}
+
+	// 12:00 UTC is 21:00 in Tokyo, inside quiet hours until 08:00 local
+	qtask := &task.Task{Timezone: "Asia/Tokyo", QuietHours: "20:00-08:00"}
+	if at := Next(qtask, now); !at.Equal(time.Date(2024, 3, 10, 23, 0, 0, 0, time.UTC)) {
+		t.Errorf("quiet hours ignored %s", at)
+	}
+
+	config.Set("schedule.quiet_hours", "11:00-13:00")
+	config.Set("schedule.lanes", []string{"bulk", "otp"})
+	config.Set("schedule.projects", []string{"shop"})
+	config.Set("lanes.otp.high", true)
+	defer func() {
+		config.Set("schedule.quiet_hours", "")
+		config.Set("schedule.lanes", nil)
+		config.Set("schedule.projects", nil)
+		config.Set("lanes.otp.high", nil)
+	}()
+
+	if d := Delay(&task.Task{Lane: "bulk"}, now); d != time.Hour {
+		t.Errorf("configured quiet hours ignored for lane %s", d)
+	}
+	if d := Delay(&task.Task{Lane: "default", Project: "shop"}, now); d != time.Hour {
+		t.Errorf("configured quiet hours ignored for project %s", d)
+	}
+	if d := Delay(&task.Task{Lane: "default", Project: "taxi"}, now); d != 0 {
+		t.Errorf("configured quiet hours applied to task not opted in %s", d)
+	}
+	if d := Delay(&task.Task{Lane: "otp", Project: "shop"}, now); d != 0 {
+		t.Errorf("configured quiet hours applied to high lane %s", d)
+	}
+}
@@ -0,0 +1,98 @@
+package spool
+
+/**
+ *	Local json lines file keeping records while their storage is unavailable
+ */
+
+import (
+	"bufio"
+	"errors"
+	"os"
+
+	"github.com/goccy/go-json"
+)
+
+type Spool[T any] struct {
+	path string
+}
+
+func New[T any](path string) *Spool[T] {
+	return &Spool[T]{path: path}
+}
+
+func (s *Spool[T]) Append(records []*T) error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return encode(f, records)
+}
+
+func encode[T any](f *os.File, records []*T) error {
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Load returns spooled records in order, missing spool is empty
+func (s *Spool[T]) Load() ([]*T, error) {
+	f, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []*T
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r T
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			// torn line after crash
+			continue
+		}
+		records = append(records, &r)
+	}
+	return records, scanner.Err()
+}
+
+// Replace keeps only given records, so written ones are not replayed twice
+func (s *Spool[T]) Replace(records []*T) error {
+	tmp := s.path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	err = encode(f, records)
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *Spool[T]) Exists() bool {
+	info, err := os.Stat(s.path)
+	return err == nil && info.Size() > 0
+}
+
+func (s *Spool[T]) Remove() error {
+	err := os.Remove(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
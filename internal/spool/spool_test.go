@@ -0,0 +1,41 @@
+package spool
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+type record struct {
+	Id int `json:"id"`
+}
+
+func TestSpool(t *testing.T) {
+	s := New[record](filepath.Join(t.TempDir(), "test.spool"))
+
+	if records, err := s.Load(); err != nil || len(records) != 0 || s.Exists() {
+		t.Fatalf("missing spool is not empty %v %v", records, err)
+	}
+
+	if err := s.Append([]*record{{Id: 1}, {Id: 2}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Append([]*record{{Id: 3}}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := s.Load()
+	if err != nil || len(records) != 3 || records[2].Id != 3 {
+		t.Fatalf("bad spool %v %v", records, err)
+	}
+
+	if err := s.Replace(records[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if records, _ = s.Load(); len(records) != 2 || records[0].Id != 2 {
+		t.Fatalf("bad replaced spool %v", records)
+	}
+
+	if err := s.Remove(); err != nil || s.Exists() {
+		t.Fatalf("spool not removed %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package suppress
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/goccy/go-json"
+
+	"push-sender/internal/admin"
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const AdminPath = "/admin/suppressions"
+
+// AddRequest is body of admin add call, ttl is go duration like "720h", empty never expires
+type AddRequest struct {
+	Entry
+	Ttl string `json:"ttl,omitempty"`
+}
+
+// RegisterHandlers serves store admin api on admin listener:
+//
+//	GET    /admin/suppressions?project=p
+//	POST   /admin/suppressions {"project":"p","platform":"android","to":"token","ttl":"720h"}
+//	DELETE /admin/suppressions?project=p&platform=android&to=token
+func RegisterHandlers(store Store) {
+	admin.HandleFunc(AdminPath, func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeJson(w, store.List(r.URL.Query().Get("project")))
+		case http.MethodPost:
+			var req AddRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if req.Project == "" || req.Platform == "" || req.To == "" {
+				http.Error(w, "project, platform and to are required", http.StatusBadRequest)
+				return
+			}
+			if req.Reason == "" {
+				req.Reason = ReasonOptOut
+			}
+			if req.Ttl != "" {
+				ttl, err := time.ParseDuration(req.Ttl)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				req.ExpiresAt = time.Now().Add(ttl)
+			}
+			entry := req.Entry
+			store.Add(&entry)
+			log.Infof("suppress: added %s %s %s (%s)", entry.Project, entry.Platform, entry.To, entry.Reason)
+			writeJson(w, &entry)
+		case http.MethodDelete:
+			q := r.URL.Query()
+			if q.Get("project") == "" || q.Get("platform") == "" || q.Get("to") == "" {
+				http.Error(w, "project, platform and to are required", http.StatusBadRequest)
+				return
+			}
+			store.Remove(q.Get("project"), task.Target{Type: task.Platform(q.Get("platform")), To: q.Get("to")})
+			log.Infof("suppress: removed %s %s %s", q.Get("project"), q.Get("platform"), q.Get("to"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func writeJson(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Errorf("suppress: cannot write reply %s", err)
+	}
+}
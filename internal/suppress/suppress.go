@@ -0,0 +1,210 @@
+package suppress
+
+/**
+ *	Recipients that must not be pushed: dead tokens and opted-out users
+ */
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+const (
+	ReasonTokenRemoved = "token_removed"
+	ReasonOptOut       = "opt_out"
+)
+
+func init() {
+	// how long removed token stays suppressed, opt-outs have own ttl
+	config.SetDefault("suppress.ttl", 7*24*time.Hour)
+	config.SetDefault("suppress.persist", false)
+	config.SetDefault("suppress.space", "push_suppressions")
+	// how often expired entries are dropped from memory and space
+	config.SetDefault("suppress.sweep_interval", time.Hour)
+}
+
+type Entry struct {
+	Project  string        `json:"project"`
+	Platform task.Platform `json:"platform"`
+	To       string        `json:"to"`
+	Reason   string        `json:"reason"`
+	// zero means never expires
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+}
+
+func (e *Entry) expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && !now.Before(e.ExpiresAt)
+}
+
+func (e *Entry) key() string {
+	return key(e.Project, task.Target{Type: e.Platform, To: e.To})
+}
+
+func key(project string, target task.Target) string {
+	return project + "\x00" + string(target.Type) + "\x00" + target.To
+}
+
+type Store interface {
+	// Suppressed returns entry blocking delivery to target
+	Suppressed(project string, target task.Target) (*Entry, bool)
+	Add(entry *Entry)
+	Remove(project string, target task.Target)
+	// List returns live entries of project, all projects if empty
+	List(project string) []*Entry
+}
+
+// persister mirrors store changes to durable storage
+type persister interface {
+	Load() ([]*Entry, error)
+	Save(entry *Entry) error
+	Delete(entry *Entry) error
+}
+
+type memStore struct {
+	mutex   sync.RWMutex
+	entries map[string]*Entry
+	persist persister
+}
+
+func NewDefaultStore() Store {
+	var s *memStore
+	if !config.GetBool("suppress.persist") {
+		s = newMemStore(nil)
+	} else {
+		s = newMemStore(newTntPersister(config.GetString("suppress.space")))
+	}
+
+	if interval := config.GetDuration("suppress.sweep_interval"); interval > 0 {
+		go func() {
+			for range time.Tick(interval) {
+				s.sweep(time.Now())
+			}
+		}()
+	}
+
+	return s
+}
+
+func newMemStore(p persister) *memStore {
+	s := &memStore{
+		entries: make(map[string]*Entry),
+		persist: p,
+	}
+
+	if p != nil {
+		entries, err := p.Load()
+		if err != nil {
+			log.Errorf("suppress: cannot load entries %s", err)
+		}
+		for _, e := range entries {
+			s.entries[e.key()] = e
+		}
+		s.sweep(time.Now())
+		log.Infof("suppress: loaded %d entries", len(s.entries))
+	}
+
+	return s
+}
+
+// sweep drops expired entries from memory and durable storage
+func (s *memStore) sweep(now time.Time) {
+	var expired []*Entry
+
+	s.mutex.Lock()
+	for k, e := range s.entries {
+		if e.expired(now) {
+			expired = append(expired, e)
+			delete(s.entries, k)
+		}
+	}
+	s.mutex.Unlock()
+
+	if s.persist == nil {
+		return
+	}
+	for _, e := range expired {
+		if err := s.persist.Delete(e); err != nil {
+			log.Errorf("suppress: cannot delete expired %s %s %s", e.Platform, e.To, err)
+		}
+	}
+}
+
+func (s *memStore) Suppressed(project string, target task.Target) (*Entry, bool) {
+	s.mutex.RLock()
+	e, ok := s.entries[key(project, target)]
+	s.mutex.RUnlock()
+
+	// expired entry is left to sweep, so it is deleted from storage too
+	if !ok || e.expired(time.Now()) {
+		return nil, false
+	}
+
+	return e, true
+}
+
+func (s *memStore) Add(entry *Entry) {
+	s.mutex.Lock()
+	s.entries[entry.key()] = entry
+	s.mutex.Unlock()
+
+	if s.persist != nil {
+		if err := s.persist.Save(entry); err != nil {
+			log.Errorf("suppress: cannot save %s %s %s", entry.Platform, entry.To, err)
+		}
+	}
+}
+
+func (s *memStore) Remove(project string, target task.Target) {
+	k := key(project, target)
+
+	s.mutex.Lock()
+	e, ok := s.entries[k]
+	delete(s.entries, k)
+	s.mutex.Unlock()
+
+	if !ok {
+		e = &Entry{Project: project, Platform: target.Type, To: target.To}
+	}
+
+	if s.persist != nil {
+		if err := s.persist.Delete(e); err != nil {
+			log.Errorf("suppress: cannot delete %s %s %s", target.Type, target.To, err)
+		}
+	}
+}
+
+func (s *memStore) List(project string) []*Entry {
+	now := time.Now()
+	list := make([]*Entry, 0)
+
+	s.mutex.RLock()
+	for _, e := range s.entries {
+		if (project == "" || e.Project == project) && !e.expired(now) {
+			list = append(list, e)
+		}
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].key() < list[j].key()
+	})
+
+	return list
+}
+
+// TokenRemoved makes entry for token rejected by provider
+func TokenRemoved(project string, target task.Target) *Entry {
+	return &Entry{
+		Project:   project,
+		Platform:  target.Type,
+		To:        target.To,
+		Reason:    ReasonTokenRemoved,
+		ExpiresAt: time.Now().Add(config.GetDuration("suppress.ttl")),
+	}
+}
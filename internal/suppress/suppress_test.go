@@ -0,0 +1,135 @@
+package suppress
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	config "github.com/spf13/viper"
+
+	"push-sender/internal/admin"
+	"push-sender/internal/task"
+)
+
+type memPersister struct {
+	saved map[string]*Entry
+}
+
+func (p *memPersister) Load() ([]*Entry, error) {
+	list := make([]*Entry, 0, len(p.saved))
+	for _, e := range p.saved {
+		list = append(list, e)
+	}
+	return list, nil
+}
+
+func (p *memPersister) Save(e *Entry) error {
+	p.saved[e.key()] = e
+	return nil
+}
+
+func (p *memPersister) Delete(e *Entry) error {
+	delete(p.saved, e.key())
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	p := &memPersister{saved: make(map[string]*Entry)}
+	s := newMemStore(p)
+
+	target := task.Target{Type: task.Android, To: "tok"}
+	s.Add(TokenRemoved("p", target))
+	s.Add(&Entry{Project: "p", Platform: task.Ios, To: "old", ExpiresAt: time.Now().Add(-time.Second)})
+
+	if e, ok := s.Suppressed("p", target); !ok || e.Reason != ReasonTokenRemoved {
+		t.Fatalf("token not suppressed")
+	}
+	if _, ok := s.Suppressed("p2", target); ok {
+		t.Fatalf("other project suppressed")
+	}
+	if _, ok := s.Suppressed("p", task.Target{Type: task.Ios, To: "old"}); ok {
+		t.Fatalf("expired entry suppressed")
+	}
+
+	// restart restores live entries only and deletes expired ones
+	s = newMemStore(p)
+	if list := s.List(""); len(list) != 1 || list[0].To != "tok" || len(p.saved) != 1 {
+		t.Fatalf("bad restored list %v saved %v", list, p.saved)
+	}
+
+	s.Remove("p", target)
+	if _, ok := s.Suppressed("p", target); ok || len(p.saved) != 0 {
+		t.Fatalf("entry not removed")
+	}
+}
+
+func TestSweep(t *testing.T) {
+	p := &memPersister{saved: make(map[string]*Entry)}
+	s := newMemStore(p)
+
+	now := time.Now()
+	s.Add(&Entry{Project: "p", Platform: task.Android, To: "live", ExpiresAt: now.Add(time.Hour)})
+	s.Add(&Entry{Project: "p", Platform: task.Android, To: "old", ExpiresAt: now.Add(time.Minute)})
+	s.Add(&Entry{Project: "p", Platform: task.Android, To: "forever"})
+
+	s.sweep(now.Add(2 * time.Minute))
+
+	if len(s.entries) != 2 || len(p.saved) != 2 {
+		t.Fatalf("expired entry not swept: memory %v saved %v", s.entries, p.saved)
+	}
+	if _, ok := p.saved[key("p", task.Target{Type: task.Android, To: "old"})]; ok {
+		t.Fatal("expired entry left in storage")
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	s := newMemStore(nil)
+	RegisterHandlers(s)
+	config.Set("admin_token", "secret")
+	defer config.Set("admin_token", "")
+	srv := httptest.NewServer(admin.Handler())
+	defer srv.Close()
+
+	do := func(method, url string, body io.Reader) (*http.Response, error) {
+		req, _ := http.NewRequest(method, url, body)
+		req.Header.Set("Authorization", "Bearer secret")
+		return http.DefaultClient.Do(req)
+	}
+
+	resp, err := http.Get(srv.URL + AdminPath)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("call without token is served %v %v", resp, err)
+	}
+
+	body, _ := json.Marshal(&AddRequest{Entry: Entry{Project: "p", Platform: task.Android, To: "tok"}, Ttl: "1h"})
+	resp, err = do(http.MethodPost, srv.URL+AdminPath, bytes.NewBuffer(body))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("add failed %v %v", resp, err)
+	}
+
+	e, ok := s.Suppressed("p", task.Target{Type: task.Android, To: "tok"})
+	if !ok || e.Reason != ReasonOptOut || e.ExpiresAt.IsZero() {
+		t.Fatalf("bad entry %#v", e)
+	}
+
+	resp, err = do(http.MethodGet, srv.URL+AdminPath+"?project=p", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []*Entry
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil || len(list) != 1 {
+		t.Fatalf("bad list %v %v", list, err)
+	}
+
+	resp, err = do(http.MethodDelete, srv.URL+AdminPath+"?project=p&platform=android&to=tok", nil)
+	if err != nil || resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("remove failed %v %v", resp, err)
+	}
+	if len(s.List("")) != 0 {
+		t.Fatalf("entry not removed")
+	}
+}
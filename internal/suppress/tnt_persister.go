@@ -0,0 +1,94 @@
+package suppress
+
+import (
+	"math"
+	"time"
+
+	"push-sender/internal/task"
+	"push-sender/internal/tnt"
+
+	tarantool "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+// tntPersister keeps entries in space with primary key {project, platform, to}:
+// [project, platform, to, reason, expires_at unix seconds or 0]
+type tntPersister struct {
+	space string
+	pool  *tnt.LazyPool
+}
+
+func newTntPersister(space string) *tntPersister {
+	return &tntPersister{
+		space: space,
+		pool:  tnt.DefaultPool(),
+	}
+}
+
+type entryTuple struct {
+	_msgpack  struct{} `msgpack:",asArray"`
+	Project   string
+	Platform  string
+	To        string
+	Reason    string
+	ExpiresAt int64
+}
+
+func (p *tntPersister) Load() ([]*Entry, error) {
+	connPool, err := p.pool.Get()
+	if err != nil {
+		return nil, err
+	}
+
+	var tuples []entryTuple
+	err = connPool.Do(tarantool.NewSelectRequest(p.space).Iterator(tarantool.IterAll).Limit(math.MaxUint32), pool.ANY).GetTyped(&tuples)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(tuples))
+	for _, t := range tuples {
+		e := &Entry{
+			Project:  t.Project,
+			Platform: task.Platform(t.Platform),
+			To:       t.To,
+			Reason:   t.Reason,
+		}
+		if t.ExpiresAt > 0 {
+			e.ExpiresAt = time.Unix(t.ExpiresAt, 0)
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+func (p *tntPersister) Save(e *Entry) error {
+	connPool, err := p.pool.Get()
+	if err != nil {
+		return err
+	}
+
+	t := &entryTuple{
+		Project:  e.Project,
+		Platform: string(e.Platform),
+		To:       e.To,
+		Reason:   e.Reason,
+	}
+	if !e.ExpiresAt.IsZero() {
+		t.ExpiresAt = e.ExpiresAt.Unix()
+	}
+
+	_, err = connPool.Do(tarantool.NewReplaceRequest(p.space).Tuple(t), pool.RW).Get()
+	return err
+}
+
+func (p *tntPersister) Delete(e *Entry) error {
+	connPool, err := p.pool.Get()
+	if err != nil {
+		return err
+	}
+
+	_, err = connPool.Do(tarantool.NewDeleteRequest(p.space).Key([]any{e.Project, string(e.Platform), e.To}), pool.RW).Get()
+	return err
+}
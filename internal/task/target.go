@@ -0,0 +1,38 @@
+package task
+
+// ParseTargets converts tnt fallback field to targets, each element is
+// either [platform, token] array or {type = platform, to = token} map
+func ParseTargets(field any) []Target {
+	list, ok := field.([]any)
+	if !ok {
+		return nil
+	}
+
+	targets := make([]Target, 0, len(list))
+
+	for _, item := range list {
+		var target Target
+		switch v := item.(type) {
+		case []any:
+			if len(v) < 2 {
+				continue
+			}
+			platform, _ := v[0].(string)
+			to, _ := v[1].(string)
+			target = Target{Type: Platform(platform), To: to}
+		case map[string]any:
+			platform, _ := v["type"].(string)
+			to, _ := v["to"].(string)
+			target = Target{Type: Platform(platform), To: to}
+		case map[any]any:
+			platform, _ := v["type"].(string)
+			to, _ := v["to"].(string)
+			target = Target{Type: Platform(platform), To: to}
+		}
+		if target.Type != "" && target.To != "" {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
@@ -1,5 +1,7 @@
 package task
 
+import "time"
+
 type Platform string
 
 const (
@@ -7,12 +9,88 @@ const (
 	Huawei  Platform = "huawei"
 	Ios     Platform = "ios"
 	Rustore Platform = "rustore"
+	WebPush Platform = "webpush"
+	Xiaomi  Platform = "xiaomi"
+	Honor   Platform = "honor"
+	Amazon  Platform = "amazon"
+	Windows Platform = "windows"
+	Webhook Platform = "webhook"
 )
 
+// Target is one delivery channel of the recipient
+type Target struct {
+	Type Platform
+	To   string
+}
+
 type Task struct {
 	ID      uint64
 	Project string   `tnt:"0,require"`
 	Type    Platform `tnt:"1,require"`
 	To      string   `tnt:"2,require"`
 	Payload any      `tnt:"3,require"`
+	// ordered channels tried one by one when delivery to To fails permanently,
+	// tuple field 4 is parsed by fetcher. Other platforms get unified message
+	// or template only, raw payload is provider specific.
+	Fallback []Target
+	// named template rendered to unified message
+	Template string `tnt:"5"`
+	// recipient locale like ru-RU, selects template variant
+	Locale string `tnt:"6"`
+	// template variables
+	Variables map[string]string `tnt:"7"`
+	// unix time the task must not be sent before, 0 sends at once
+	SendAt int64 `tnt:"8"`
+	// recipient IANA timezone like Europe/Moscow, quiet hours are local to it
+	Timezone string `tnt:"9"`
+	// recipient quiet hours window like 22:00-08:00
+	QuietHours string `tnt:"10"`
+	// unix time the task was put to queue
+	EnqueuedAt int64 `tnt:"11"`
+	// unix time the message becomes useless, 0 never expires
+	ExpiresAt int64 `tnt:"12"`
+	// priority lane the task was taken from
+	Lane string
+	// channel the task was finally delivered through, nil if not delivered
+	Delivered *Target
+	// provider id of accepted message, set by transport when provider returns one
+	MessageId string
+	// recipient id provider asks to use instead of To from now on, set by transport
+	Canonical string
+}
+
+// Ttl returns remaining lifetime of the message, false if it never expires
+func (t *Task) Ttl(now time.Time) (time.Duration, bool) {
+	if t.ExpiresAt == 0 {
+		return 0, false
+	}
+	return time.Unix(t.ExpiresAt, 0).Sub(now), true
+}
+
+// LimitTtl cuts provider ttl in seconds to remaining lifetime of the message,
+// 0 ttl means provider default, at least one second is returned for expiring task
+func (t *Task) LimitTtl(ttl int, now time.Time) int {
+	remaining, ok := t.Ttl(now)
+	if !ok {
+		return ttl
+	}
+	seconds := max(int(remaining/time.Second), 1)
+	if ttl == 0 || ttl > seconds {
+		return seconds
+	}
+	return ttl
+}
+
+// Targets returns primary target followed by fallback chain
+func (t *Task) Targets() []Target {
+	return append([]Target{{Type: t.Type, To: t.To}}, t.Fallback...)
+}
+
+// ForTarget returns copy of the task addressed to target
+func (t *Task) ForTarget(target Target) *Task {
+	copyTask := *t
+	copyTask.Type = target.Type
+	copyTask.To = target.To
+	copyTask.Fallback = nil
+	return &copyTask
 }
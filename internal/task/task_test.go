@@ -0,0 +1,26 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimitTtl(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	if ttl := (&Task{}).LimitTtl(3600, now); ttl != 3600 {
+		t.Errorf("ttl without expiry changed to %d", ttl)
+	}
+
+	qtask := &Task{ExpiresAt: now.Add(time.Minute).Unix()}
+	cases := map[int]int{3600: 60, 0: 60, 10: 10}
+	for ttl, want := range cases {
+		if got := qtask.LimitTtl(ttl, now); got != want {
+			t.Errorf("ttl %d limited to %d, want %d", ttl, got, want)
+		}
+	}
+
+	if ttl := qtask.LimitTtl(0, now.Add(2*time.Minute)); ttl != 1 {
+		t.Errorf("expired task ttl %d", ttl)
+	}
+}
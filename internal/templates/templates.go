@@ -0,0 +1,257 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"push-sender/internal/push"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+var ErrUnknownTemplate = errors.New("unknown template")
+
+func init() {
+	config.SetDefault("template_default_locale", "en")
+}
+
+// Store renders named templates to unified messages
+type Store interface {
+	Render(name string, locale string, vars map[string]string) (*push.Message, error)
+}
+
+// localized is one locale variant of a template
+type localized struct {
+	title    *template.Template
+	body     *template.Template
+	image    *template.Template
+	sound    *template.Template
+	category *template.Template
+	data     map[string]*template.Template
+}
+
+// configStore reads templates from config section
+//
+//	templates:
+//	  order_ready:
+//	    en:
+//	      title: "Order {{.order}} is ready"
+//	      body: "Pick it up at {{.address}}"
+//	    ru:
+//	      title: "Заказ {{.order}} готов"
+//	      body: "Заберите его по адресу {{.address}}"
+type configStore struct {
+	mutex sync.RWMutex
+	cache map[string]*localized
+}
+
+var defaultStore = newConfigStore()
+
+func newConfigStore() *configStore {
+	return &configStore{
+		cache: make(map[string]*localized),
+	}
+}
+
+// Default returns store backed by config, it is reset by Reload
+func Default() Store {
+	return defaultStore
+}
+
+// Reload drops parsed templates, used as runner config change callback
+func Reload(_ string) {
+	defaultStore.mutex.Lock()
+	defer defaultStore.mutex.Unlock()
+	defaultStore.cache = make(map[string]*localized)
+	log.Info("templates: reloaded")
+}
+
+// LocaleChain returns locales tried in order: configured fallback list or
+// the locale and its language, then default locale
+func LocaleChain(locale string) []string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+
+	chain := config.GetStringSlice("template_locale_fallback." + locale)
+	if len(chain) == 0 && locale != "" {
+		chain = []string{locale}
+		if lang, _, ok := strings.Cut(locale, "-"); ok {
+			chain = append(chain, lang)
+		}
+	}
+	chain = append(chain, config.GetString("template_default_locale"))
+
+	seen := make(map[string]struct{}, len(chain))
+	res := make([]string, 0, len(chain))
+	for _, l := range chain {
+		l = strings.ToLower(l)
+		if _, ok := seen[l]; ok || l == "" {
+			continue
+		}
+		seen[l] = struct{}{}
+		res = append(res, l)
+	}
+	return res
+}
+
+func (s *configStore) get(name string, locale string) (*localized, error) {
+	key := name + "." + locale
+
+	s.mutex.RLock()
+	tmpl, ok := s.cache[key]
+	s.mutex.RUnlock()
+
+	if ok {
+		return tmpl, nil
+	}
+
+	section := config.GetStringMap("templates." + key)
+	if len(section) == 0 {
+		return nil, nil
+	}
+
+	tmpl, err := parse(key, section)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	s.cache[key] = tmpl
+	s.mutex.Unlock()
+
+	return tmpl, nil
+}
+
+func parse(key string, section map[string]any) (*localized, error) {
+	tmpl := &localized{
+		data: make(map[string]*template.Template),
+	}
+
+	fields := map[string]**template.Template{
+		"title":    &tmpl.title,
+		"body":     &tmpl.body,
+		"image":    &tmpl.image,
+		"sound":    &tmpl.sound,
+		"category": &tmpl.category,
+	}
+
+	for field, dst := range fields {
+		text, ok := section[field].(string)
+		if !ok {
+			continue
+		}
+		t, err := template.New(key + "." + field).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("template %s.%s: %w", key, field, err)
+		}
+		*dst = t
+	}
+
+	if data, ok := section["data"].(map[string]any); ok {
+		for k, v := range data {
+			t, err := template.New(key + ".data." + k).Option("missingkey=error").Parse(fmt.Sprintf("%v", v))
+			if err != nil {
+				return nil, fmt.Errorf("template %s.data.%s: %w", key, k, err)
+			}
+			tmpl.data[k] = t
+		}
+	}
+
+	return tmpl, nil
+}
+
+func execute(t *template.Template, vars map[string]string) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (s *configStore) Render(name string, locale string, vars map[string]string) (*push.Message, error) {
+	for _, l := range LocaleChain(locale) {
+		tmpl, err := s.get(name, l)
+		if err != nil {
+			return nil, err
+		}
+		if tmpl != nil {
+			return tmpl.render(vars)
+		}
+	}
+
+	return nil, fmt.Errorf("%w %s [%s]", ErrUnknownTemplate, name, locale)
+}
+
+func (tmpl *localized) render(vars map[string]string) (msg *push.Message, err error) {
+	msg = &push.Message{}
+
+	fields := []struct {
+		t   *template.Template
+		dst *string
+	}{
+		{tmpl.title, &msg.Title},
+		{tmpl.body, &msg.Body},
+		{tmpl.image, &msg.Image},
+		{tmpl.sound, &msg.Sound},
+		{tmpl.category, &msg.Category},
+	}
+
+	for _, f := range fields {
+		if *f.dst, err = execute(f.t, vars); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(tmpl.data) > 0 {
+		msg.Data = make(map[string]string, len(tmpl.data))
+		for k, t := range tmpl.data {
+			if msg.Data[k], err = execute(t, vars); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return msg, nil
+}
+
+// Apply renders template over unified message, message delivery options are kept
+func Apply(store Store, name string, locale string, vars map[string]string, base *push.Message) (*push.Message, error) {
+	msg, err := store.Render(name, locale, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	if base == nil {
+		return msg, nil
+	}
+
+	res := *base
+	res.Title, res.Body = msg.Title, msg.Body
+	if msg.Image != "" {
+		res.Image = msg.Image
+	}
+	if msg.Sound != "" {
+		res.Sound = msg.Sound
+	}
+	if msg.Category != "" {
+		res.Category = msg.Category
+	}
+	if len(msg.Data) > 0 {
+		res.Data = make(map[string]string, len(base.Data)+len(msg.Data))
+		for k, v := range base.Data {
+			res.Data[k] = v
+		}
+		for k, v := range msg.Data {
+			res.Data[k] = v
+		}
+	}
+
+	return &res, nil
+}
@@ -0,0 +1,42 @@
+package templates
+
+import (
+	"errors"
+	"testing"
+
+	config "github.com/spf13/viper"
+)
+
+func TestRender(t *testing.T) {
+	config.Set("templates", map[string]any{
+		"order_ready": map[string]any{
+			"en": map[string]any{
+				"title": "Order {{.order}} is ready",
+				"data":  map[string]any{"order_id": "{{.order}}"},
+			},
+			"ru": map[string]any{
+				"title": "Заказ {{.order}} готов",
+			},
+		},
+	})
+
+	Reload("templates")
+
+	msg, err := Default().Render("order_ready", "ru-RU", map[string]string{"order": "42"})
+	if err != nil || msg.Title != "Заказ 42 готов" {
+		t.Errorf("bad ru render %#v %s", msg, err)
+	}
+
+	msg, err = Default().Render("order_ready", "de", map[string]string{"order": "42"})
+	if err != nil || msg.Title != "Order 42 is ready" || msg.Data["order_id"] != "42" {
+		t.Errorf("bad fallback render %#v %s", msg, err)
+	}
+
+	if _, err := Default().Render("order_ready", "en", map[string]string{}); err == nil {
+		t.Error("expected missing variable error")
+	}
+
+	if _, err := Default().Render("unknown", "en", nil); !errors.Is(err, ErrUnknownTemplate) {
+		t.Errorf("expected unknown template, got %s", err)
+	}
+}
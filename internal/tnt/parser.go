@@ -192,8 +192,9 @@ func ScanFieldsToStruct(fields []interface{}, dst interface{}) error {
 
 		if len(tagParts) > 1 {
 			for _, v := range tagParts[1:] {
-				if v == TAG_REQUIRE && require < index {
-					require = index
+				// require counts fields up to the last required one
+				if v == TAG_REQUIRE && require < index+1 {
+					require = index + 1
 				}
 			}
 		}
@@ -241,6 +242,11 @@ func ScanFields(fields []interface{}, require int, dst ...interface{}) error {
 			return nil
 		}
 
+		// optional field is left empty
+		if f == nil && i >= require {
+			continue
+		}
+
 		switch d := dst[i].(type) {
 		case *string:
 			x, ok := StringOrIntToString(f)
@@ -286,11 +292,23 @@ func ScanFields(fields []interface{}, require int, dst ...interface{}) error {
 				return fmt.Errorf("field #%d `%v`", i, fields)
 			}
 			*d = x
+		case *interface{}:
+			*d = f
 		case noop:
 			// do nothing
 		default:
+			// named string types like task.Platform
+			v := reflect.ValueOf(dst[i])
+			if v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.String {
+				x, ok := StringOrIntToString(f)
+				if !ok {
+					return fmt.Errorf("field #%d `%#v` convert to string", i, fields[i])
+				}
+				v.Elem().SetString(x)
+				continue
+			}
 			// wrong dst type
-			return fmt.Errorf("unknown destination #%d type, %t", i, dst[i])
+			return fmt.Errorf("unknown destination #%d type, %T", i, dst[i])
 		}
 	}
 
@@ -307,6 +325,13 @@ func MapToMapStrings(field interface{}) (map[string]string, bool) {
 			}
 		}
 	}
+	if mapsStr, ok := field.(map[string]interface{}); ok {
+		for k, v := range mapsStr {
+			if v1, ok1 := StringOrIntToString(v); ok1 {
+				maps[k] = v1
+			}
+		}
+	}
 	return maps, true
 }
 
@@ -314,6 +339,9 @@ func IntOrStringToInt(field interface{}) (int64, bool) {
 	var ret int64
 	var ok bool
 	if ret, ok = field.(int64); !ok {
+		if ret, ok = smallIntToInt(field); ok {
+			return ret, ok
+		}
 		var retstr string
 		if retstr, ok = field.(string); ok {
 			i, _ := strconv.Atoi(retstr)
@@ -328,10 +356,36 @@ func IntOrStringToInt(field interface{}) (int64, bool) {
 	return ret, ok
 }
 
+// smallIntToInt converts compact msgpack integers, decoder returns the narrowest type
+func smallIntToInt(field interface{}) (int64, bool) {
+	switch v := field.(type) {
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	}
+	return 0, false
+}
+
 func IntOrStringToUint(field interface{}) (uint64, bool) {
 	var ret uint64
 	var ok bool
 	if ret, ok = field.(uint64); !ok {
+		if i, small := smallIntToInt(field); small && i >= 0 {
+			return uint64(i), true
+		}
 		var retstr string
 		if retstr, ok = field.(string); ok {
 			i, _ := strconv.Atoi(retstr)
@@ -353,6 +407,10 @@ func StringOrIntToString(field interface{}) (string, bool) {
 		var retInt uint64
 		if retInt, ok = field.(uint64); ok {
 			ret = fmt.Sprintf("%d", retInt)
+		} else if small, isInt := smallIntToInt(field); isInt {
+			ret, ok = strconv.FormatInt(small, 10), true
+		} else if i64, isInt := field.(int64); isInt {
+			ret, ok = strconv.FormatInt(i64, 10), true
 		} else {
 			var retfloat float64
 			if retfloat, ok = field.(float64); ok {
@@ -0,0 +1,177 @@
+package tnt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	config "github.com/spf13/viper"
+	tarantool "github.com/tarantool/go-tarantool/v2"
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+func init() {
+	// shortest pause between failed connect of lazy pool and next one
+	config.SetDefault("tarantool.reconnect_delay", 5*time.Second)
+}
+
+// NewDefaultCfg reads connection settings from tarantool config section
+func NewDefaultCfg() *TntCfg {
+	return &TntCfg{
+		Addrs:    config.GetStringSlice("tarantool.queue"),
+		User:     config.GetString("tarantool.user"),
+		Password: config.GetString("tarantool.password"),
+		Timeout:  config.GetDuration("tarantool.timeout"),
+	}
+}
+
+// Connect opens connection pool to all cfg instances, handler may be nil
+func Connect(ctx context.Context, cfg *TntCfg, handler pool.ConnectionHandler) (*pool.ConnectionPool, error) {
+	poolInstances := []pool.Instance{}
+	connOpts := tarantool.Opts{
+		Timeout: cfg.Timeout,
+	}
+	for _, serv := range cfg.Addrs {
+		dialer := tarantool.NetDialer{
+			Address:  serv,
+			User:     cfg.User,
+			Password: cfg.Password,
+		}
+		poolInstances = append(poolInstances, pool.Instance{
+			Name:   serv,
+			Dialer: dialer,
+			Opts:   connOpts,
+		})
+	}
+
+	poolOpts := pool.Opts{
+		CheckTimeout:      5 * time.Second,
+		ConnectionHandler: handler,
+	}
+
+	return pool.ConnectWithOpts(ctx, poolInstances, poolOpts)
+}
+
+// ErrNotConnected is returned while pool connects or waits to reconnect
+var ErrNotConnected = errors.New("tarantool is not connected")
+
+// LazyPool connects on first use so callers can start while tarantool is down.
+// Connect runs in background without lock, failed one is retried not sooner
+// than tarantool.reconnect_delay and callers fail fast meanwhile.
+type LazyPool struct {
+	cfg   *TntCfg
+	delay time.Duration
+	dial  func(ctx context.Context, cfg *TntCfg) (*pool.ConnectionPool, error)
+
+	mutex    sync.Mutex
+	pool     *pool.ConnectionPool
+	dialing  chan struct{}
+	err      error
+	failedAt time.Time
+}
+
+func NewLazyPool(cfg *TntCfg) *LazyPool {
+	return &LazyPool{
+		cfg:   cfg,
+		delay: config.GetDuration("tarantool.reconnect_delay"),
+		dial: func(ctx context.Context, cfg *TntCfg) (*pool.ConnectionPool, error) {
+			return Connect(ctx, cfg, nil)
+		},
+	}
+}
+
+var (
+	sharedMutex sync.Mutex
+	shared      = make(map[string]*LazyPool)
+)
+
+// SharedPool returns one lazy pool per connection settings
+func SharedPool(cfg *TntCfg) *LazyPool {
+	key := fmt.Sprintf("%v\x00%s\x00%s\x00%s", cfg.Addrs, cfg.User, cfg.Password, cfg.Timeout)
+
+	sharedMutex.Lock()
+	defer sharedMutex.Unlock()
+
+	p, ok := shared[key]
+	if !ok {
+		p = NewLazyPool(cfg)
+		shared[key] = p
+	}
+	return p
+}
+
+// DefaultPool returns shared pool of tarantool config section
+func DefaultPool() *LazyPool {
+	return SharedPool(NewDefaultCfg())
+}
+
+// start returns connected pool, recent connect error or channel closed when connect is done
+func (p *LazyPool) start() (*pool.ConnectionPool, <-chan struct{}, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.pool != nil {
+		return p.pool, nil, nil
+	}
+	if p.dialing != nil {
+		return nil, p.dialing, nil
+	}
+	if p.err != nil && time.Since(p.failedAt) < p.delay {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNotConnected, p.err)
+	}
+
+	done := make(chan struct{})
+	p.dialing = done
+	go p.connect(done)
+
+	return nil, done, nil
+}
+
+func (p *LazyPool) connect(done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.Timeout+time.Second)
+	defer cancel()
+
+	connPool, err := p.dial(ctx, p.cfg)
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.dialing = nil
+	p.err = err
+	if err != nil {
+		p.failedAt = time.Now()
+		return
+	}
+	p.pool = connPool
+}
+
+// Get returns pool, it waits for connect when tarantool is not connected yet
+func (p *LazyPool) Get() (*pool.ConnectionPool, error) {
+	connPool, done, err := p.start()
+	if done == nil {
+		return connPool, err
+	}
+
+	<-done
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.pool == nil {
+		return nil, p.err
+	}
+	return p.pool, nil
+}
+
+// Current returns pool without waiting, for hot path callers with local fallback.
+// Connect is started in background for next calls.
+func (p *LazyPool) Current() (*pool.ConnectionPool, error) {
+	connPool, done, err := p.start()
+	if done != nil {
+		return nil, ErrNotConnected
+	}
+	return connPool, err
+}
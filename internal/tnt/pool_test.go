@@ -0,0 +1,73 @@
+package tnt
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tarantool/go-tarantool/v2/pool"
+)
+
+func TestLazyPoolBackoff(t *testing.T) {
+	var dials atomic.Int32
+	p := NewLazyPool(&TntCfg{Timeout: time.Second})
+	p.delay = time.Hour
+	p.dial = func(ctx context.Context, cfg *TntCfg) (*pool.ConnectionPool, error) {
+		dials.Add(1)
+		return nil, errors.New("refused")
+	}
+
+	if _, err := p.Get(); err == nil || errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected connect error got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := p.Get(); !errors.Is(err, ErrNotConnected) {
+			t.Fatalf("expected fail fast got %v", err)
+		}
+	}
+	if dials.Load() != 1 {
+		t.Fatalf("failed connect is retried %d times within delay", dials.Load())
+	}
+
+	p.mutex.Lock()
+	p.failedAt = time.Now().Add(-2 * time.Hour)
+	p.mutex.Unlock()
+
+	p.Get()
+	if dials.Load() != 2 {
+		t.Fatalf("connect is not retried after delay")
+	}
+}
+
+func TestLazyPoolCurrent(t *testing.T) {
+	release := make(chan struct{})
+	p := NewLazyPool(&TntCfg{Timeout: time.Second})
+	p.dial = func(ctx context.Context, cfg *TntCfg) (*pool.ConnectionPool, error) {
+		<-release
+		return nil, errors.New("refused")
+	}
+
+	start := time.Now()
+	if _, err := p.Current(); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected not connected got %v", err)
+	}
+	if _, err := p.Current(); !errors.Is(err, ErrNotConnected) {
+		t.Fatalf("expected not connected got %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Fatal("current waits for connect")
+	}
+	close(release)
+}
+
+func TestSharedPool(t *testing.T) {
+	a := SharedPool(&TntCfg{Addrs: []string{"a:3301"}, User: "u"})
+	if a != SharedPool(&TntCfg{Addrs: []string{"a:3301"}, User: "u"}) {
+		t.Error("same settings get different pools")
+	}
+	if a == SharedPool(&TntCfg{Addrs: []string{"b:3301"}, User: "u"}) {
+		t.Error("different settings share pool")
+	}
+}
@@ -118,30 +118,7 @@ func NewTntQueue(ctx context.Context, name string, cfg *TntCfg) (tqueue Queue, e
 
 	tntQueue.handler = NewQueueConnectionHandler(name, qCfg)
 
-	poolInstances := []pool.Instance{}
-	connOpts := tarantool.Opts{
-		Timeout: cfg.Timeout,
-	}
-	for _, serv := range cfg.Addrs {
-		dialer := tarantool.NetDialer{
-			Address:  serv,
-			User:     cfg.User,
-			Password: cfg.Password,
-		}
-		poolInstances = append(poolInstances, pool.Instance{
-			Name:   serv,
-			Dialer: dialer,
-			Opts:   connOpts,
-		})
-
-	}
-
-	poolOpts := pool.Opts{
-		CheckTimeout:      5 * time.Second,
-		ConnectionHandler: tntQueue.handler,
-	}
-
-	connPool, err := pool.ConnectWithOpts(ctx, poolInstances, poolOpts)
+	connPool, err := Connect(ctx, cfg, tntQueue.handler)
 
 	if err != nil {
 		log.Errorf("Unable to connect to the pool: %s", err)
@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"encoding/json"
+	"push-sender/internal/push"
+	"push-sender/internal/push/adm"
+	"push-sender/internal/task"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// admClient is implemented by adm.AdmSender
+type admClient interface {
+	Send(to string, msg *adm.AdmMessage, opts *adm.AdmMessageOpts) (string, error)
+}
+
+type admSender struct {
+	admSender admClient
+	admConfig AdmConfig
+}
+
+func (a *admSender) Send(task *task.Task) error {
+	var msg adm.AdmMessage
+
+	// unified message, plain data map like android or json message with consolidation options
+	if unified, ok := push.ParseMessage(task.Payload); ok {
+		msg = *adm.RenderMessage(unified)
+	} else {
+		switch payload := task.Payload.(type) {
+		case map[string]string:
+			msg.Data = payload
+		case string:
+			if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+				log.Errorf("adm: bad payload %s %s", payload, err)
+				return push.ErrorRequest
+			}
+		default:
+			log.Errorf("adm: bad payload %v", task.Payload)
+			return push.ErrorRequest
+		}
+	}
+
+	opts := a.admConfig.GetConfig(task.Project)
+	opts.TimeToLive = task.LimitTtl(opts.TimeToLive, time.Now())
+	if msg.ExpiresAfter > 0 {
+		msg.ExpiresAfter = task.LimitTtl(msg.ExpiresAfter, time.Now())
+	}
+
+	registrationId, err := a.admSender.Send(task.To, &msg, opts)
+	if err == nil && registrationId != "" && registrationId != task.To {
+		task.Canonical = registrationId
+	}
+	return err
+}
+
+func NewAdmTransport() Transport {
+	return &admSender{
+		admSender: adm.New(),
+		admConfig: newDefaultAdmConfig(),
+	}
+}
@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"push-sender/internal/push/adm"
+
+	config "github.com/spf13/viper"
+)
+
+type AdmConfig interface {
+	GetConfig(projectId string) *adm.AdmMessageOpts
+}
+
+type defaultAdmConfig struct {
+}
+
+func newDefaultAdmConfig() AdmConfig {
+	return &defaultAdmConfig{}
+}
+
+func (c *defaultAdmConfig) GetConfig(projectId string) *adm.AdmMessageOpts {
+	return &adm.AdmMessageOpts{
+		ClientId:     config.GetString(projectId + ".adm_client_id"),
+		ClientSecret: config.GetString(projectId + ".adm_client_secret"),
+		TimeToLive:   config.GetInt(projectId + ".ttl"),
+	}
+}
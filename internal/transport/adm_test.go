@@ -0,0 +1,53 @@
+package transport
+
+import (
+	"push-sender/internal/push/adm"
+	"push-sender/internal/task"
+	"push-sender/internal/validate"
+	"reflect"
+	"testing"
+)
+
+type fakeAdm struct {
+	msg            *adm.AdmMessage
+	registrationId string
+}
+
+func (f *fakeAdm) Send(to string, msg *adm.AdmMessage, opts *adm.AdmMessageOpts) (string, error) {
+	f.msg = msg
+	return f.registrationId, nil
+}
+
+// raw data map is sent as adm data, fetcher gives it as map[string]string
+func TestAdmRawData(t *testing.T) {
+	fake := &fakeAdm{}
+	sender := &admSender{admSender: fake, admConfig: newDefaultAdmConfig()}
+
+	data := map[string]string{"order": "42", "kind": "ready"}
+	qtask := &task.Task{Project: "com.example.app", Type: task.Amazon, To: "token", Payload: data}
+
+	if err := validate.Task(qtask); err != nil {
+		t.Fatal(err)
+	}
+	if err := sender.Send(qtask); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fake.msg.Data, data) {
+		t.Fatalf("bad data %v", fake.msg.Data)
+	}
+}
+
+func TestAdmCanonical(t *testing.T) {
+	fake := &fakeAdm{registrationId: "token"}
+	sender := &admSender{admSender: fake, admConfig: newDefaultAdmConfig()}
+
+	qtask := &task.Task{Project: "com.example.app", Type: task.Amazon, To: "token", Payload: map[string]string{"k": "v"}}
+	if err := sender.Send(qtask); err != nil || qtask.Canonical != "" {
+		t.Fatalf("same registration id is canonical %q %v", qtask.Canonical, err)
+	}
+
+	fake.registrationId = "new-token"
+	if err := sender.Send(qtask); err != nil || qtask.Canonical != "new-token" {
+		t.Fatalf("canonical registration id is lost %q %v", qtask.Canonical, err)
+	}
+}
@@ -1,9 +1,11 @@
 package transport
 
 import (
+	"fmt"
 	"push-sender/internal/push"
 	"push-sender/internal/push/android"
 	"push-sender/internal/task"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -25,14 +27,28 @@ func (a *sAndroid) Send(task *task.Task) error {
 		a.Opts[task.Project] = opts
 	}
 
-	payload, ok := task.Payload.(map[string]string)
+	var fcmMsg *android.FcmMessageProto
 
-	if !ok {
-		log.Errorf("android: bad payload %v", task.Payload)
-		return push.ErrorRequest
+	if msg, ok := push.ParseMessage(task.Payload); ok {
+		fcmMsg = android.RenderMessage(msg, opts)
+	} else {
+		payload, ok := task.Payload.(map[string]string)
+
+		if !ok {
+			log.Errorf("android: bad payload %v", task.Payload)
+			return push.ErrorRequest
+		}
+
+		fcmMsg = &android.FcmMessageProto{}
+		fcmMsg.Message.Data = payload
+		if _, ok := task.Ttl(time.Now()); ok {
+			fcmMsg.Message.Android = &android.AndroidConfig{TTL: fmt.Sprintf("%ds", task.LimitTtl(opts.TimeToLive, time.Now()))}
+		}
 	}
 
-	return a.androidSender.Send(task.To, payload, opts)
+	messageId, err := a.androidSender.SendProto(task.To, fcmMsg, opts)
+	task.MessageId = messageId
+	return err
 }
 
 func NewAndroidTransport() Transport {
@@ -0,0 +1,37 @@
+package transport
+
+import (
+	"push-sender/internal/breaker"
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+
+	config "github.com/spf13/viper"
+)
+
+// breakerTransport short-circuits sends to platform of project while provider is down
+type breakerTransport struct {
+	next     Transport
+	breakers *breaker.Registry
+}
+
+func WithBreaker(next Transport) Transport {
+	return &breakerTransport{
+		next:     next,
+		breakers: breaker.Default(),
+	}
+}
+
+func (b *breakerTransport) Send(qtask *task.Task) error {
+	if !config.GetBool("breaker.enabled") {
+		return b.next.Send(qtask)
+	}
+
+	done, wait, ok := b.breakers.Get(string(qtask.Type) + "/" + qtask.Project).Allow()
+	if !ok {
+		return push.NewRetryError(push.ErrorCircuitOpen, wait)
+	}
+
+	err := b.next.Send(qtask)
+	done(err)
+	return err
+}
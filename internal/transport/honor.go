@@ -0,0 +1,40 @@
+package transport
+
+import (
+	"push-sender/internal/push"
+	"push-sender/internal/push/honor"
+	"push-sender/internal/task"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type honorSender struct {
+	honorSender *honor.HonorSender
+	honorConfig HonorConfig
+}
+
+func (a *honorSender) Send(task *task.Task) error {
+	opts := a.honorConfig.GetConfig(task.Project)
+	opts.TimeToLive = task.LimitTtl(opts.TimeToLive, time.Now())
+
+	if msg, ok := push.ParseMessage(task.Payload); ok {
+		return a.honorSender.SendMessage(task.To, msg, opts)
+	}
+
+	payload, ok := task.Payload.(string)
+
+	if !ok {
+		log.Errorf("honor: bad payload %v", task.Payload)
+		return push.ErrorRequest
+	}
+
+	return a.honorSender.Send(task.To, payload, opts)
+}
+
+func NewHonorTransport() Transport {
+	return &honorSender{
+		honorSender: honor.New(),
+		honorConfig: newDefaultHonorConfig(),
+	}
+}
@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"push-sender/internal/push/honor"
+
+	config "github.com/spf13/viper"
+)
+
+type HonorConfig interface {
+	GetConfig(projectId string) *honor.HonorMessageOpts
+}
+
+type defaultHonorConfig struct {
+}
+
+func newDefaultHonorConfig() HonorConfig {
+	return &defaultHonorConfig{}
+}
+
+func (c *defaultHonorConfig) GetConfig(projectId string) *honor.HonorMessageOpts {
+	return &honor.HonorMessageOpts{
+		AppId:        config.GetString(projectId + ".honor_app_id"),
+		ClientId:     config.GetString(projectId + ".honor_client_id"),
+		ClientSecret: config.GetString(projectId + ".honor_client_secret"),
+	}
+}
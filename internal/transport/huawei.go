@@ -1,9 +1,11 @@
 package transport
 
 import (
+	"fmt"
 	"push-sender/internal/push"
 	"push-sender/internal/push/huawei"
 	"push-sender/internal/task"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -16,14 +18,27 @@ type huaweiSender struct {
 func (a *huaweiSender) Send(task *task.Task) error {
 	opts := a.hmsConfig.GetConfig(task.Project)
 
-	payload, ok := task.Payload.(string)
+	var inner *huawei.InnerMessage
 
-	if !ok {
-		log.Errorf("android: bad payload %v", task.Payload)
-		return push.ErrorRequest
+	if msg, ok := push.ParseMessage(task.Payload); ok {
+		inner = huawei.RenderMessage(msg)
+	} else {
+		payload, ok := task.Payload.(string)
+
+		if !ok {
+			log.Errorf("android: bad payload %v", task.Payload)
+			return push.ErrorRequest
+		}
+
+		inner = &huawei.InnerMessage{Data: payload}
+		if ttl := task.LimitTtl(0, time.Now()); ttl > 0 {
+			inner.Android = &huawei.HmsAndroidConfig{TTL: fmt.Sprintf("%ds", ttl)}
+		}
 	}
 
-	return a.hmsSender.Send(task.To, payload, opts)
+	requestId, err := a.hmsSender.SendInner(task.To, inner, opts)
+	task.MessageId = requestId
+	return err
 }
 
 func NewHuaweiTransport() Transport {
@@ -1,6 +1,9 @@
 package transport
 
 import (
+	"strings"
+	"time"
+
 	"push-sender/internal/push"
 	"push-sender/internal/push/ios"
 	"push-sender/internal/task"
@@ -8,23 +11,76 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// apnsSender is implemented by ios.IosSender
+type apnsSender interface {
+	SendPayload(token string, payload *ios.Payload, headers *ios.ApnsHeaders, opts *ios.ApnsOptions) (string, error)
+	SendWithHeaders(token string, payload string, headers *ios.ApnsHeaders, opts *ios.ApnsOptions) (string, error)
+	Broadcast(channelId string, payload string, headers *ios.ApnsHeaders, opts *ios.ApnsOptions) (string, error)
+}
+
 type iosSender struct {
-	apnsSender  *ios.IosSender
+	apnsSender  apnsSender
 	apnsConfigs ApnsConfig
 }
 
 func NewIosTransport() Transport {
 	return &iosSender{
 		apnsSender:  ios.New(),
-		apnsConfigs: newDefaultApnsConfig(),
+		apnsConfigs: NewDefaultApnsConfig(),
 	}
 }
 
-func (a *iosSender) Send(task *task.Task) error {
+func (a *iosSender) Send(task *task.Task) (err error) {
+	opts := a.apnsConfigs.GetConfig(task.Project)
+
+	if msg, ok := push.ParseMessage(task.Payload); ok {
+		payload, headers := ios.RenderMessage(msg)
+		if strings.HasPrefix(task.To, ios.ChannelPrefix) {
+			return a.broadcast(task, payload, headers, opts)
+		}
+		task.MessageId, err = a.apnsSender.SendPayload(task.To, payload, headers, opts)
+		return
+	}
+
 	payload, ok := task.Payload.(string)
 	if !ok {
 		log.Errorf("ios: bad payload %v", task.Payload)
 		return push.ErrorRequest
 	}
-	return a.apnsSender.Send(task.To, payload, a.apnsConfigs.GetConfig(task.Project))
+
+	headers := ios.DetectHeaders(payload)
+	if remaining, ok := task.Ttl(time.Now()); ok {
+		// plain alert has no detected headers
+		if headers == nil {
+			headers = &ios.ApnsHeaders{}
+		}
+		if expiration := time.Now().Add(remaining); headers.Expiration.IsZero() || headers.Expiration.After(expiration) {
+			headers.Expiration = expiration
+		}
+	}
+
+	if strings.HasPrefix(task.To, ios.ChannelPrefix) {
+		task.MessageId, err = a.apnsSender.Broadcast(task.To, payload, headers, opts)
+		return
+	}
+
+	task.MessageId, err = a.apnsSender.SendWithHeaders(task.To, payload, headers, opts)
+	return
+}
+
+// broadcast sends rendered message to live activity channel subscribers
+func (a *iosSender) broadcast(task *task.Task, payload *ios.Payload, headers *ios.ApnsHeaders, opts *ios.ApnsOptions) (err error) {
+	pushType := ios.PushTypeAlert
+	if headers != nil && headers.PushType != "" {
+		pushType = headers.PushType
+	}
+
+	data, err := payload.Build(pushType)
+	if err != nil {
+		log.Errorf("ios: bad channel payload %s", err)
+		return push.ErrorRequest
+	}
+
+	task.MessageId, err = a.apnsSender.Broadcast(task.To, data, headers, opts)
+	return
 }
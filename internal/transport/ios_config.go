@@ -13,7 +13,7 @@ type ApnsConfig interface {
 type defaultApnsConfig struct {
 }
 
-func newDefaultApnsConfig() ApnsConfig {
+func NewDefaultApnsConfig() ApnsConfig {
 	return &defaultApnsConfig{}
 }
 
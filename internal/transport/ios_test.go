@@ -0,0 +1,91 @@
+package transport
+
+import (
+	"push-sender/internal/push"
+	"push-sender/internal/push/ios"
+	"push-sender/internal/task"
+	"testing"
+	"time"
+)
+
+type fakeApns struct {
+	headers   *ios.ApnsHeaders
+	broadcast string
+}
+
+func (f *fakeApns) SendPayload(token string, payload *ios.Payload, headers *ios.ApnsHeaders, opts *ios.ApnsOptions) (string, error) {
+	f.headers = headers
+	return "apns-id", nil
+}
+
+func (f *fakeApns) SendWithHeaders(token string, payload string, headers *ios.ApnsHeaders, opts *ios.ApnsOptions) (string, error) {
+	f.headers = headers
+	return "apns-id", nil
+}
+
+func (f *fakeApns) Broadcast(channelId string, payload string, headers *ios.ApnsHeaders, opts *ios.ApnsOptions) (string, error) {
+	f.headers = headers
+	f.broadcast = channelId
+	return "apns-id", nil
+}
+
+func TestIosRawExpiration(t *testing.T) {
+	fake := &fakeApns{}
+	sender := &iosSender{apnsSender: fake, apnsConfigs: NewDefaultApnsConfig()}
+
+	expiresAt := time.Now().Add(time.Minute)
+	qtask := &task.Task{
+		Project:   "com.example.app",
+		Type:      task.Ios,
+		To:        "token",
+		Payload:   `{"aps":{"alert":"hi"}}`,
+		ExpiresAt: expiresAt.Unix(),
+	}
+
+	if err := sender.Send(qtask); err != nil {
+		t.Fatal(err)
+	}
+	if fake.headers == nil || fake.headers.Expiration.Unix() != expiresAt.Unix() {
+		t.Fatalf("apns-expiration is not set from task expiry %v", fake.headers)
+	}
+	if qtask.MessageId != "apns-id" {
+		t.Errorf("message id is not set %s", qtask.MessageId)
+	}
+
+	qtask.ExpiresAt = 0
+	if err := sender.Send(qtask); err != nil {
+		t.Fatal(err)
+	}
+	if fake.headers != nil {
+		t.Errorf("headers set for task without expiry %v", fake.headers)
+	}
+}
+
+// unified message to channel recipient is broadcast like raw payload
+func TestIosMessageChannel(t *testing.T) {
+	fake := &fakeApns{}
+	sender := &iosSender{apnsSender: fake, apnsConfigs: NewDefaultApnsConfig()}
+
+	qtask := &task.Task{
+		Project: "com.example.app",
+		Type:    task.Ios,
+		To:      ios.ChannelPrefix + "dHN0LXNyY2gtY2hubA==",
+		Payload: &push.Message{Title: "hi", Body: "there"},
+	}
+
+	if err := sender.Send(qtask); err != nil {
+		t.Fatal(err)
+	}
+	if fake.broadcast != qtask.To {
+		t.Fatalf("channel recipient is not broadcast %q", fake.broadcast)
+	}
+
+	fake.broadcast = ""
+	qtask.To = "token"
+	if err := sender.Send(qtask); err != nil {
+		t.Fatal(err)
+	}
+	if fake.broadcast != "" {
+		t.Fatalf("device token is broadcast %q", fake.broadcast)
+	}
+}
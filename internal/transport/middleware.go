@@ -0,0 +1,77 @@
+package transport
+
+import (
+	"sync"
+
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+func init() {
+	// outermost first, platform list replaces default one:
+	//
+	//	transport_middleware:
+	//	  default: [breaker]
+	//	  ios: [logging, metrics, breaker]
+	config.SetDefault("transport_middleware.default", []string{"breaker"})
+
+	RegisterMiddleware("breaker", WithBreaker)
+	RegisterMiddleware("logging", WithLogging)
+	RegisterMiddleware("metrics", WithMetrics)
+	RegisterMiddleware("dry_run", WithDryRun)
+}
+
+// Middleware decorates transport with cross-cutting behaviour
+type Middleware func(next Transport) Transport
+
+// TransportFunc adapts function to Transport
+type TransportFunc func(qtask *task.Task) error
+
+func (f TransportFunc) Send(qtask *task.Task) error {
+	return f(qtask)
+}
+
+var (
+	middlewaresMutex sync.RWMutex
+	middlewares      = make(map[string]Middleware)
+)
+
+// RegisterMiddleware makes middleware available by name in config
+func RegisterMiddleware(name string, m Middleware) {
+	middlewaresMutex.Lock()
+	defer middlewaresMutex.Unlock()
+	middlewares[name] = m
+}
+
+// Chain wraps t so that the first middleware is the outermost
+func Chain(t Transport, chain ...Middleware) Transport {
+	for i := len(chain) - 1; i >= 0; i-- {
+		t = chain[i](t)
+	}
+	return t
+}
+
+// Wrap applies middlewares configured for platform
+func Wrap(platform task.Platform, t Transport) Transport {
+	key := "transport_middleware." + string(platform)
+	if !config.IsSet(key) {
+		key = "transport_middleware.default"
+	}
+
+	middlewaresMutex.RLock()
+	defer middlewaresMutex.RUnlock()
+
+	chain := make([]Middleware, 0)
+	for _, name := range config.GetStringSlice(key) {
+		m, ok := middlewares[name]
+		if !ok {
+			log.Errorf("transport: unknown middleware %s for %s", name, platform)
+			continue
+		}
+		chain = append(chain, m)
+	}
+
+	return Chain(t, chain...)
+}
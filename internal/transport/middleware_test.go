@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"testing"
+
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+
+	config "github.com/spf13/viper"
+)
+
+func TestChain(t *testing.T) {
+	var calls []string
+	trace := func(name string) Middleware {
+		return func(next Transport) Transport {
+			return TransportFunc(func(qtask *task.Task) error {
+				calls = append(calls, name)
+				return next.Send(qtask)
+			})
+		}
+	}
+
+	sender := TransportFunc(func(qtask *task.Task) error {
+		calls = append(calls, "send")
+		return push.ErrorTokenRemoved
+	})
+
+	RegisterMiddleware("first", trace("first"))
+	RegisterMiddleware("second", trace("second"))
+	config.Set("transport_middleware.ios", []string{"first", "unknown", "second"})
+	defer config.Set("transport_middleware.ios", nil)
+
+	err := Wrap(task.Ios, sender).Send(&task.Task{Type: task.Ios})
+	if err != push.ErrorTokenRemoved {
+		t.Fatalf("error lost %v", err)
+	}
+	if len(calls) != 3 || calls[0] != "first" || calls[1] != "second" || calls[2] != "send" {
+		t.Fatalf("bad order %v", calls)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	sender := TransportFunc(func(qtask *task.Task) error {
+		t.Fatalf("provider called in dry run")
+		return nil
+	})
+
+	qtask := &task.Task{Type: task.Android}
+	if err := Chain(sender, WithMetrics, WithDryRun).Send(qtask); err != nil || qtask.MessageId == "" {
+		t.Fatalf("dry run failed %v", err)
+	}
+}
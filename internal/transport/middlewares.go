@@ -0,0 +1,56 @@
+package transport
+
+import (
+	"expvar"
+	"time"
+
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WithLogging logs every send with its outcome and duration
+func WithLogging(next Transport) Transport {
+	return TransportFunc(func(qtask *task.Task) error {
+		started := time.Now()
+		err := next.Send(qtask)
+		fields := log.Fields{
+			"task":     qtask.ID,
+			"project":  qtask.Project,
+			"platform": qtask.Type,
+			"duration": time.Since(started),
+		}
+		if err != nil {
+			log.WithFields(fields).Infof("transport: send failed %s", err)
+		} else {
+			log.WithFields(fields).Infof("transport: sent %s", qtask.MessageId)
+		}
+		return err
+	})
+}
+
+// sends counts sends as "<platform>.sent" and "<platform>.<error code>", served at /debug/vars
+var sends = expvar.NewMap("transport")
+
+// WithMetrics counts sends by platform and error code
+func WithMetrics(next Transport) Transport {
+	return TransportFunc(func(qtask *task.Task) error {
+		err := next.Send(qtask)
+		if err != nil {
+			sends.Add(string(qtask.Type)+"."+push.ErrorCode(err), 1)
+		} else {
+			sends.Add(string(qtask.Type)+".sent", 1)
+		}
+		return err
+	})
+}
+
+// WithDryRun accepts every task without calling provider
+func WithDryRun(next Transport) Transport {
+	return TransportFunc(func(qtask *task.Task) error {
+		log.Infof("transport: dry run task %d %s %s", qtask.ID, qtask.Type, qtask.To)
+		qtask.MessageId = "dry-run"
+		return nil
+	})
+}
@@ -1,6 +1,9 @@
 package transport
 
 import (
+	"strings"
+	"time"
+
 	"push-sender/internal/push"
 	"push-sender/internal/push/rustore"
 	"push-sender/internal/task"
@@ -13,6 +16,13 @@ type rustoreSender struct {
 }
 
 func (a *rustoreSender) Send(task *task.Task) error {
+	opts := a.rustoreConfig.GetConfig(task.Project)
+	opts.TimeToLive = task.LimitTtl(opts.TimeToLive, time.Now())
+
+	if msg, ok := push.ParseMessage(task.Payload); ok {
+		return rustore.SendMessage(task.To, msg, opts)
+	}
+
 	payload, ok := task.Payload.(string)
 
 	if !ok {
@@ -20,11 +30,15 @@ func (a *rustoreSender) Send(task *task.Task) error {
 		return push.ErrorRequest
 	}
 
-	return rustore.Send(task.To, payload, a.rustoreConfig.GetConfig(task.Project))
+	if strings.HasPrefix(task.To, rustore.TopicPrefix) {
+		return rustore.SendTopic(task.To, payload, opts)
+	}
+
+	return rustore.Send(task.To, payload, opts)
 }
 
 func NewRustoreTransport() Transport {
 	return &rustoreSender{
-		rustoreConfig: newDefaultRustoreConfig(),
+		rustoreConfig: NewDefaultRustoreConfig(),
 	}
 }
@@ -13,7 +13,7 @@ type RustoreConfig interface {
 type defaultRustoreConfig struct {
 }
 
-func newDefaultRustoreConfig() RustoreConfig {
+func NewDefaultRustoreConfig() RustoreConfig {
 	return &defaultRustoreConfig{}
 }
 
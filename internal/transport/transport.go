@@ -16,6 +16,18 @@ func GetTransport(t task.Platform) Transport {
 		return NewRustoreTransport()
 	case task.Ios:
 		return NewIosTransport()
+	case task.WebPush:
+		return NewWebPushTransport()
+	case task.Xiaomi:
+		return NewXiaomiTransport()
+	case task.Honor:
+		return NewHonorTransport()
+	case task.Amazon:
+		return NewAdmTransport()
+	case task.Windows:
+		return NewWnsTransport()
+	case task.Webhook:
+		return NewWebhookTransport()
 	}
 	return nil
 }
@@ -0,0 +1,39 @@
+package transport
+
+import (
+	"push-sender/internal/push"
+	"push-sender/internal/push/webhook"
+	"push-sender/internal/task"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type webhookSender struct {
+	webhookConfig WebhookConfig
+}
+
+func (a *webhookSender) Send(task *task.Task) error {
+	var payload string
+
+	if msg, ok := push.ParseMessage(task.Payload); ok {
+		payload = webhook.RenderMessage(msg)
+	} else if payload, ok = task.Payload.(string); !ok {
+		log.Errorf("webhook: bad payload %v", task.Payload)
+		return push.ErrorRequest
+	}
+
+	to, opts := a.webhookConfig.GetConfig(task.Project, task.To)
+
+	if to == "" {
+		log.Errorf("webhook: unknown endpoint %s", task.To)
+		return push.ErrorRequest
+	}
+
+	return webhook.Send(to, payload, opts)
+}
+
+func NewWebhookTransport() Transport {
+	return &webhookSender{
+		webhookConfig: newDefaultWebhookConfig(),
+	}
+}
@@ -0,0 +1,73 @@
+package transport
+
+import (
+	"net/url"
+	"push-sender/internal/push/webhook"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
+)
+
+type WebhookConfig interface {
+	// GetConfig resolves task recipient, an url or a name of endpoint in webhooks section,
+	// empty url means recipient is not allowed
+	GetConfig(projectId string, to string) (string, *webhook.WebhookOpts)
+}
+
+type defaultWebhookConfig struct {
+}
+
+func newDefaultWebhookConfig() WebhookConfig {
+	return &defaultWebhookConfig{}
+}
+
+func (c *defaultWebhookConfig) GetConfig(projectId string, to string) (string, *webhook.WebhookOpts) {
+	opts := &webhook.WebhookOpts{
+		ProjectId:    projectId,
+		Secret:       config.GetString(projectId + ".webhook_secret"),
+		Timeout:      config.GetDuration("webhook_timeout"),
+		SuccessCodes: config.GetIntSlice("webhook_success_codes"),
+		Headers:      config.GetStringMapString(projectId + ".webhook_headers"),
+	}
+
+	if strings.Contains(to, "://") {
+		if !allowedUrl(projectId, to) {
+			log.Errorf("webhook: url %s is not allowed for %s", to, projectId)
+			return "", opts
+		}
+		return to, opts
+	}
+
+	endpoint := "webhooks." + to
+
+	if config.IsSet(endpoint + ".secret") {
+		opts.Secret = config.GetString(endpoint + ".secret")
+	}
+	if config.IsSet(endpoint + ".timeout") {
+		opts.Timeout = config.GetDuration(endpoint + ".timeout")
+	}
+	if config.IsSet(endpoint + ".success_codes") {
+		opts.SuccessCodes = config.GetIntSlice(endpoint + ".success_codes")
+	}
+	if config.IsSet(endpoint + ".headers") {
+		opts.Headers = config.GetStringMapString(endpoint + ".headers")
+	}
+
+	return config.GetString(endpoint + ".url"), opts
+}
+
+// allowedUrl reports url is https and its host matches project webhook_hosts,
+// so project secret and headers are not sent to hosts chosen by producer
+func allowedUrl(projectId string, to string) bool {
+	u, err := url.Parse(to)
+	if err != nil || u.Scheme != "https" {
+		return false
+	}
+	for _, host := range config.GetStringSlice(projectId + ".webhook_hosts") {
+		if u.Hostname() == host || (strings.HasPrefix(host, ".") && strings.HasSuffix(u.Hostname(), host)) {
+			return true
+		}
+	}
+	return false
+}
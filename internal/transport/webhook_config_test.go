@@ -0,0 +1,34 @@
+package transport
+
+import (
+	"testing"
+
+	config "github.com/spf13/viper"
+)
+
+func TestWebhookConfig(t *testing.T) {
+	config.Set("shop.webhook_hosts", []string{"hooks.example.com", ".partner.net"})
+	config.Set("webhooks.crm.url", "http://crm.local/push")
+	defer config.Set("shop.webhook_hosts", nil)
+
+	c := newDefaultWebhookConfig()
+
+	cases := map[string]string{
+		"crm":                                 "http://crm.local/push",
+		"https://hooks.example.com/push":      "https://hooks.example.com/push",
+		"https://eu.partner.net/push":         "https://eu.partner.net/push",
+		"http://hooks.example.com/push":       "",
+		"https://evil.example.com/push":       "",
+		"https://hooks.example.com.evil/push": "",
+		"https://169.254.169.254/latest":      "",
+	}
+	for to, want := range cases {
+		if got, _ := c.GetConfig("shop", to); got != want {
+			t.Errorf("%s resolved to %q, want %q", to, got, want)
+		}
+	}
+
+	if got, _ := c.GetConfig("other", "https://hooks.example.com/push"); got != "" {
+		t.Errorf("url allowed for project without hosts %s", got)
+	}
+}
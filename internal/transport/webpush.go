@@ -0,0 +1,48 @@
+package transport
+
+import (
+	"push-sender/internal/push"
+	"push-sender/internal/push/webpush"
+	"push-sender/internal/task"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type webPushSender struct {
+	webPushSender *webpush.WebPushSender
+	webPushConfig WebPushConfig
+}
+
+func (a *webPushSender) Send(task *task.Task) error {
+	// task recipient is a PushSubscription json
+	sub, err := webpush.ParseSubscription(task.To)
+
+	if err != nil {
+		// malformed subscription is a producer bug, the device may still be alive
+		log.Errorf("webpush: bad subscription %s %s", task.To, err)
+		return push.ErrorRequest
+	}
+
+	opts := a.webPushConfig.GetConfig(task.Project)
+
+	var payload string
+
+	if msg, ok := push.ParseMessage(task.Payload); ok {
+		payload = webpush.RenderMessage(msg, opts)
+	} else if payload, ok = task.Payload.(string); !ok {
+		log.Errorf("webpush: bad payload %v", task.Payload)
+		return push.ErrorRequest
+	}
+
+	opts.TimeToLive = task.LimitTtl(opts.TimeToLive, time.Now())
+
+	return a.webPushSender.Send(sub, payload, opts)
+}
+
+func NewWebPushTransport() Transport {
+	return &webPushSender{
+		webPushSender: webpush.New(),
+		webPushConfig: newDefaultWebPushConfig(),
+	}
+}
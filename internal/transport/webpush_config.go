@@ -0,0 +1,33 @@
+package transport
+
+import (
+	"push-sender/internal/push/webpush"
+
+	config "github.com/spf13/viper"
+)
+
+type WebPushConfig interface {
+	GetConfig(projectId string) *webpush.WebPushOpts
+}
+
+type defaultWebPushConfig struct {
+}
+
+func newDefaultWebPushConfig() WebPushConfig {
+	return &defaultWebPushConfig{}
+}
+
+func (c *defaultWebPushConfig) GetConfig(projectId string) *webpush.WebPushOpts {
+	ttl := config.GetInt("webpush_ttl")
+	if config.IsSet(projectId + ".ttl") {
+		ttl = config.GetInt(projectId + ".ttl")
+	}
+
+	return &webpush.WebPushOpts{
+		ProjectId:       projectId,
+		VapidPrivateKey: config.GetString(projectId + ".vapid_private_key"),
+		Subject:         config.GetString(projectId + ".vapid_subject"),
+		TimeToLive:      ttl,
+		Urgency:         config.GetString(projectId + ".urgency"),
+	}
+}
@@ -0,0 +1,49 @@
+package transport
+
+import (
+	"encoding/json"
+	"push-sender/internal/push"
+	"push-sender/internal/push/wns"
+	"push-sender/internal/task"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type wnsSender struct {
+	wnsSender *wns.WnsSender
+	wnsConfig WnsConfig
+}
+
+func (a *wnsSender) Send(task *task.Task) error {
+	var msg *wns.WnsMessage
+
+	if unified, ok := push.ParseMessage(task.Payload); ok {
+		msg = wns.RenderMessage(unified)
+	} else {
+		payload, ok := task.Payload.(string)
+
+		if !ok {
+			log.Errorf("wns: bad payload %v", task.Payload)
+			return push.ErrorRequest
+		}
+
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			log.Errorf("wns: bad payload %s %s", payload, err)
+			return push.ErrorRequest
+		}
+	}
+
+	opts := a.wnsConfig.GetConfig(task.Project)
+	opts.TimeToLive = task.LimitTtl(opts.TimeToLive, time.Now())
+
+	// task recipient is a channel uri
+	return a.wnsSender.Send(task.To, msg, opts)
+}
+
+func NewWnsTransport() Transport {
+	return &wnsSender{
+		wnsSender: wns.New(),
+		wnsConfig: newDefaultWnsConfig(),
+	}
+}
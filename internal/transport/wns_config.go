@@ -0,0 +1,27 @@
+package transport
+
+import (
+	"push-sender/internal/push/wns"
+
+	config "github.com/spf13/viper"
+)
+
+type WnsConfig interface {
+	GetConfig(projectId string) *wns.WnsMessageOpts
+}
+
+type defaultWnsConfig struct {
+}
+
+func newDefaultWnsConfig() WnsConfig {
+	return &defaultWnsConfig{}
+}
+
+func (c *defaultWnsConfig) GetConfig(projectId string) *wns.WnsMessageOpts {
+	return &wns.WnsMessageOpts{
+		ClientId:     config.GetString(projectId + ".wns_client_id"),
+		ClientSecret: config.GetString(projectId + ".wns_client_secret"),
+		TenantId:     config.GetString(projectId + ".wns_tenant_id"),
+		TimeToLive:   config.GetInt(projectId + ".ttl"),
+	}
+}
@@ -0,0 +1,52 @@
+package transport
+
+import (
+	"push-sender/internal/push"
+	"push-sender/internal/push/xiaomi"
+	"push-sender/internal/task"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type xiaomiSender struct {
+	xiaomiConfig XiaomiConfig
+}
+
+func (a *xiaomiSender) Send(task *task.Task) error {
+	opts := a.xiaomiConfig.GetConfig(task.Project)
+
+	var msg *xiaomi.XiaomiMessage
+
+	if unified, ok := push.ParseMessage(task.Payload); ok {
+		msg = xiaomi.RenderMessage(unified)
+		if unified.TTL > 0 {
+			opts.TimeToLive = unified.TTL
+		}
+	} else {
+		payload, ok := task.Payload.(string)
+
+		if !ok {
+			log.Errorf("xiaomi: bad payload %v", task.Payload)
+			return push.ErrorRequest
+		}
+
+		var err error
+		msg, err = xiaomi.ParseMessage(payload)
+
+		if err != nil {
+			log.Errorf("xiaomi: bad payload %s %s", payload, err)
+			return push.ErrorRequest
+		}
+	}
+
+	opts.TimeToLive = task.LimitTtl(opts.TimeToLive, time.Now())
+
+	return xiaomi.Send(task.To, msg, opts)
+}
+
+func NewXiaomiTransport() Transport {
+	return &xiaomiSender{
+		xiaomiConfig: newDefaultXiaomiConfig(),
+	}
+}
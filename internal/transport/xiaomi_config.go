@@ -0,0 +1,26 @@
+package transport
+
+import (
+	"push-sender/internal/push/xiaomi"
+
+	config "github.com/spf13/viper"
+)
+
+type XiaomiConfig interface {
+	GetConfig(projectId string) *xiaomi.XiaomiMessageOpts
+}
+
+type defaultXiaomiConfig struct {
+}
+
+func newDefaultXiaomiConfig() XiaomiConfig {
+	return &defaultXiaomiConfig{}
+}
+
+func (c *defaultXiaomiConfig) GetConfig(projectId string) *xiaomi.XiaomiMessageOpts {
+	return &xiaomi.XiaomiMessageOpts{
+		AppSecret:   config.GetString(projectId + ".app_secret"),
+		PackageName: config.GetString(projectId + ".package_name"),
+		TimeToLive:  config.GetInt(projectId + ".ttl"),
+	}
+}
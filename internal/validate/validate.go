@@ -0,0 +1,319 @@
+package validate
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"push-sender/internal/push"
+	"push-sender/internal/push/adm"
+	"push-sender/internal/push/android"
+	"push-sender/internal/push/honor"
+	"push-sender/internal/push/huawei"
+	"push-sender/internal/push/ios"
+	"push-sender/internal/push/rustore"
+	"push-sender/internal/push/webpush"
+	"push-sender/internal/push/wns"
+	"push-sender/internal/push/xiaomi"
+	"push-sender/internal/task"
+)
+
+// Validator checks task addressed to one platform before it is sent
+type Validator func(qtask *task.Task) error
+
+var validators = map[task.Platform]Validator{
+	task.Android: validateAndroid,
+	task.Ios:     validateIos,
+	task.Huawei:  validateHuawei,
+	task.Rustore: validateRustore,
+	task.WebPush: validateWebPush,
+	task.Xiaomi:  validateXiaomi,
+	task.Honor:   validateHonor,
+	task.Amazon:  validateAdm,
+	task.Windows: validateWns,
+	task.Webhook: validateWebhook,
+}
+
+// invalid wraps push.ErrorRequest with precise reason
+func invalid(format string, args ...any) error {
+	return fmt.Errorf("%w: "+format, append([]any{push.ErrorRequest}, args...)...)
+}
+
+// Task checks recipient and payload of the task for its platform
+func Task(qtask *task.Task) error {
+	if qtask.To == "" {
+		return invalid("empty recipient")
+	}
+	if qtask.Payload == nil {
+		return invalid("empty payload")
+	}
+
+	validator, ok := validators[qtask.Type]
+	if !ok {
+		return invalid("unknown platform %s", qtask.Type)
+	}
+
+	return validator(qtask)
+}
+
+func checkSize(name string, size int, limit int) error {
+	if size > limit {
+		return invalid("%s payload size %d exceeds %d", name, size, limit)
+	}
+	return nil
+}
+
+func checkJson(name string, payload string) error {
+	if !json.Valid([]byte(payload)) {
+		return invalid("%s payload is not valid json", name)
+	}
+	return nil
+}
+
+func checkMessage(msg *push.Message) error {
+	if msg.Title == "" && msg.Body == "" && len(msg.Data) == 0 {
+		return invalid("unified message has neither notification nor data")
+	}
+	if msg.TTL < 0 {
+		return invalid("negative ttl %d", msg.TTL)
+	}
+	switch msg.Priority {
+	case "", push.PriorityNormal, push.PriorityHigh:
+	default:
+		return invalid("unknown priority %s", msg.Priority)
+	}
+	return nil
+}
+
+// stringPayload returns raw provider payload, unified messages are checked by caller
+func stringPayload(qtask *task.Task) (string, error) {
+	payload, ok := qtask.Payload.(string)
+	if !ok {
+		return "", invalid("%s payload should be string, got %T", qtask.Type, qtask.Payload)
+	}
+	return payload, nil
+}
+
+func checkAndroidData(data map[string]string) error {
+	size := 0
+	for k, v := range data {
+		if android.IsReservedKey(k) {
+			return invalid("android reserved data key %s", k)
+		}
+		size += len(k) + len(v)
+	}
+	return checkSize("android", size, android.MAX_SIZE)
+}
+
+func validateAndroid(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		j, _ := json.Marshal(android.RenderMessage(msg, &android.FcmMessageOpts{}).Message)
+		if err := checkSize("android", len(j), android.MAX_SIZE); err != nil {
+			return err
+		}
+		return checkAndroidData(msg.Data)
+	}
+
+	data, ok := qtask.Payload.(map[string]string)
+	if !ok {
+		return invalid("android payload should be map[string]string, got %T", qtask.Payload)
+	}
+
+	return checkAndroidData(data)
+}
+
+func validateIos(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		payload, headers := ios.RenderMessage(msg)
+		if _, err := payload.Build(headers.PushType); err != nil {
+			return invalid("%s", err)
+		}
+		return nil
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		return invalid("ios payload is not json object")
+	}
+	if _, ok := raw["aps"]; !ok {
+		return invalid("ios payload has no aps dictionary")
+	}
+
+	return checkSize("ios", len(payload), ios.MaxPayloadSize)
+}
+
+func validateHuawei(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		j, _ := json.Marshal(huawei.RenderMessage(msg))
+		return checkSize("huawei", len(j), huawei.MAX_SIZE)
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	return checkSize("huawei", len(payload), huawei.MAX_SIZE)
+}
+
+func validateRustore(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		j, _ := json.Marshal(rustore.RenderMessage(msg))
+		return checkSize("rustore", len(j), rustore.MAX_SIZE)
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	return checkSize("rustore", len(payload), rustore.MAX_SIZE)
+}
+
+func validateWebPush(qtask *task.Task) error {
+	if _, err := webpush.ParseSubscription(qtask.To); err != nil {
+		return invalid("bad webpush subscription %s", err)
+	}
+
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		return checkSize("webpush", len(webpush.RenderMessage(msg, &webpush.WebPushOpts{})), webpush.MAX_SIZE)
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	return checkSize("webpush", len(payload), webpush.MAX_SIZE)
+}
+
+func validateXiaomi(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		j, _ := json.Marshal(xiaomi.RenderMessage(msg))
+		return checkSize("xiaomi", len(j), xiaomi.MAX_SIZE)
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	msg, err := xiaomi.ParseMessage(payload)
+	if err != nil {
+		return invalid("xiaomi payload is not valid json")
+	}
+	if msg.PassThrough == 0 && msg.Title == "" && msg.Description == "" {
+		return invalid("xiaomi notification requires title or description")
+	}
+
+	return checkSize("xiaomi", len(payload), xiaomi.MAX_SIZE)
+}
+
+func validateHonor(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		j, _ := json.Marshal(honor.RenderMessage(msg))
+		return checkSize("honor", len(j), honor.MAX_SIZE)
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	return checkSize("honor", len(payload), honor.MAX_SIZE)
+}
+
+func validateAdm(qtask *task.Task) error {
+	size := 0
+
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		for k, v := range adm.RenderMessage(msg).Data {
+			size += len(k) + len(v)
+		}
+		return checkSize("adm", size, adm.MAX_SIZE)
+	}
+
+	switch payload := qtask.Payload.(type) {
+	case map[string]string:
+		for k, v := range payload {
+			size += len(k) + len(v)
+		}
+	case string:
+		var msg adm.AdmMessage
+		if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+			return invalid("adm payload is not valid json")
+		}
+		for k, v := range msg.Data {
+			size += len(k) + len(v)
+		}
+	default:
+		return invalid("adm payload should be map or string, got %T", qtask.Payload)
+	}
+
+	return checkSize("adm", size, adm.MAX_SIZE)
+}
+
+func validateWns(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		if err := checkMessage(msg); err != nil {
+			return err
+		}
+		return checkSize("wns", len(wns.RenderMessage(msg).Payload), wns.MAX_SIZE)
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	var msg wns.WnsMessage
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		return invalid("wns payload is not valid json")
+	}
+	if !msg.Valid() {
+		return invalid("wns payload has bad type %s or empty payload", msg.Type)
+	}
+
+	return checkSize("wns", len(msg.Payload), wns.MAX_SIZE)
+}
+
+func validateWebhook(qtask *task.Task) error {
+	if msg, ok := push.ParseMessage(qtask.Payload); ok {
+		return checkMessage(msg)
+	}
+
+	payload, err := stringPayload(qtask)
+	if err != nil {
+		return err
+	}
+
+	return checkJson("webhook", payload)
+}
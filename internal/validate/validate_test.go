@@ -0,0 +1,47 @@
+package validate
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"push-sender/internal/push"
+	"push-sender/internal/task"
+)
+
+func TestTask(t *testing.T) {
+	cases := []struct {
+		name  string
+		task  task.Task
+		valid bool
+	}{
+		{"android", task.Task{Type: task.Android, To: "t", Payload: map[string]string{"id": "1"}}, true},
+		{"android reserved", task.Task{Type: task.Android, To: "t", Payload: map[string]string{"google.x": "1"}}, false},
+		{"android unified", task.Task{Type: task.Android, To: "t", Payload: `{"unified":{"title":"hi"}}`}, true},
+		{"ios", task.Task{Type: task.Ios, To: "t", Payload: `{"aps":{"alert":"hi"}}`}, true},
+		{"ios no aps", task.Task{Type: task.Ios, To: "t", Payload: `{"alert":"hi"}`}, false},
+		{"ios too large", task.Task{Type: task.Ios, To: "t", Payload: `{"aps":{},"x":"` + strings.Repeat("x", 4096) + `"}`}, false},
+		{"huawei too large", task.Task{Type: task.Huawei, To: "t", Payload: strings.Repeat("x", 4097)}, false},
+		{"rustore", task.Task{Type: task.Rustore, To: "t", Payload: `{"id":1}`}, true},
+		{"webhook bad json", task.Task{Type: task.Webhook, To: "inbox", Payload: `{`}, false},
+		{"xiaomi unified", task.Task{Type: task.Xiaomi, To: "t", Payload: `{"unified":{"title":"hi"}}`}, true},
+		{"honor unified", task.Task{Type: task.Honor, To: "t", Payload: &push.Message{Body: "hi"}}, true},
+		{"adm unified", task.Task{Type: task.Amazon, To: "t", Payload: &push.Message{Data: map[string]string{"id": "1"}}}, true},
+		{"wns unified", task.Task{Type: task.Windows, To: "t", Payload: &push.Message{Title: "hi"}}, true},
+		{"webhook unified", task.Task{Type: task.Webhook, To: "inbox", Payload: &push.Message{Title: "hi"}}, true},
+		{"webpush unified", task.Task{Type: task.WebPush, To: `{"endpoint":"https://push.example.com/x","keys":{"p256dh":"x","auth":"y"}}`, Payload: &push.Message{Title: "hi"}}, true},
+		{"webpush empty unified", task.Task{Type: task.WebPush, To: `{"endpoint":"https://push.example.com/x","keys":{"p256dh":"x","auth":"y"}}`, Payload: &push.Message{}}, false},
+		{"unknown", task.Task{Type: "sms", To: "t", Payload: "x"}, false},
+		{"empty recipient", task.Task{Type: task.Rustore, Payload: "x"}, false},
+	}
+
+	for _, c := range cases {
+		err := Task(&c.task)
+		if c.valid && err != nil {
+			t.Errorf("%s: unexpected error %s", c.name, err)
+		}
+		if !c.valid && !errors.Is(err, push.ErrorRequest) {
+			t.Errorf("%s: expected request error, got %v", c.name, err)
+		}
+	}
+}
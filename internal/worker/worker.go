@@ -1,32 +1,276 @@
 package worker
 
 import (
+	"errors"
+	"push-sender/internal/concurrency"
+	"push-sender/internal/feedback"
+	"push-sender/internal/fetcher"
+	"push-sender/internal/push"
+	"push-sender/internal/ratelimit"
+	"push-sender/internal/results"
+	"push-sender/internal/suppress"
 	"push-sender/internal/task"
+	"push-sender/internal/templates"
 	"push-sender/internal/transport"
+	"push-sender/internal/validate"
 	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	config "github.com/spf13/viper"
 )
 
+func init() {
+	// release delay of temporary failed task when provider gives no Retry-After
+	config.SetDefault("retry_delay", 10*time.Second)
+	// lifetime of task without own expiry counted from enqueue time, 0 never expires
+	config.SetDefault("message_ttl", 0)
+}
+
 type Worker interface {
-	Start(channel <-chan *task.Task, wg *sync.WaitGroup)
+	// Start processes tasks until channels are closed or quit is signaled
+	Start(channels Channels, quit <-chan struct{}, wg *sync.WaitGroup)
+}
+
+// Channels feed worker, high priority tasks are taken first,
+// Normal is nil for workers reserved to high priority lanes
+type Channels struct {
+	High   <-chan *task.Task
+	Normal <-chan *task.Task
+}
+
+// Env is shared by all workers of application
+type Env struct {
+	// Source acks and releases tasks taken by fetcher
+	Source      fetcher.Fetcher
+	Results     results.Sink
+	Feedback    feedback.Feedback
+	Suppress    suppress.Store
+	Limiter     *ratelimit.Limiter
+	Concurrency *concurrency.Controller
 }
 
 type defaultWorker struct {
+	// transports keep provider auth tokens and connections between tasks
+	transports map[task.Platform]transport.Transport
+	env        *Env
 }
 
-func NewDefaultWorker() Worker {
-	return &defaultWorker{}
+func NewDefaultWorker(env *Env) Worker {
+	return &defaultWorker{
+		transports: make(map[task.Platform]transport.Transport),
+		env:        env,
+	}
 }
 
-func (dw *defaultWorker) Start(channel <-chan *task.Task, wg *sync.WaitGroup) {
+func (dw *defaultWorker) Start(channels Channels, quit <-chan struct{}, wg *sync.WaitGroup) {
 	go func(dw *defaultWorker) {
 		defer wg.Done()
-		for qtask := range channel {
-			dw.push(qtask)
+		// closed channel is set to nil, nil channel is never selected
+		high, normal := channels.High, channels.Normal
+		for high != nil || normal != nil {
+			select {
+			case <-quit:
+				return
+			case qtask, ok := <-high:
+				if !ok {
+					high = nil
+				} else {
+					dw.process(qtask)
+				}
+				continue
+			default:
+			}
+
+			select {
+			case <-quit:
+				return
+			case qtask, ok := <-high:
+				if !ok {
+					high = nil
+					continue
+				}
+				dw.process(qtask)
+			case qtask, ok := <-normal:
+				if !ok {
+					normal = nil
+					continue
+				}
+				dw.process(qtask)
+			}
 		}
 	}(dw)
 }
 
-func (dw *defaultWorker) push(qtask *task.Task) {
-	sender := transport.GetTransport(qtask.Type)
-	sender.Send(qtask)
+func (dw *defaultWorker) process(qtask *task.Task) {
+	startedAt := time.Now()
+
+	if dw.expired(qtask, startedAt) {
+		log.Infof("worker: task %d expired, drop it", qtask.ID)
+		dw.complete(qtask, 0, push.ErrorExpired, startedAt)
+		return
+	}
+
+	sends, err := dw.push(qtask)
+	dw.complete(qtask, sends, err, startedAt)
+}
+
+// expired sets task expiry from enqueue time and message_ttl if task has none,
+// less than a second left is expired as providers count ttl in seconds
+func (dw *defaultWorker) expired(qtask *task.Task, now time.Time) bool {
+	if qtask.ExpiresAt == 0 && qtask.EnqueuedAt > 0 {
+		if ttl := config.GetDuration("message_ttl"); ttl > 0 {
+			qtask.ExpiresAt = time.Unix(qtask.EnqueuedAt, 0).Add(ttl).Unix()
+		}
+	}
+
+	ttl, ok := qtask.Ttl(now)
+	return ok && ttl < time.Second
+}
+
+// limitTtl cuts unified message ttl to task remaining lifetime
+func (dw *defaultWorker) limitTtl(qtask *task.Task) {
+	msg, ok := push.ParseMessage(qtask.Payload)
+	if !ok {
+		return
+	}
+
+	if ttl := qtask.LimitTtl(msg.TTL, time.Now()); ttl != msg.TTL {
+		limited := *msg
+		limited.TTL = ttl
+		qtask.Payload = &limited
+	}
+}
+
+// complete acks final outcome or releases task to be retried after delay
+func (dw *defaultWorker) complete(qtask *task.Task, sends int, err error, startedAt time.Time) {
+	if err != nil && !push.IsPermanent(err) {
+		delay, ok := push.RetryAfter(err)
+		if !ok {
+			delay = config.GetDuration("retry_delay")
+		}
+		if err := dw.env.Source.Release(qtask, delay); err != nil {
+			log.Errorf("worker: cannot release task %d %s", qtask.ID, err)
+		}
+		log.Debugf("worker: task %d released for %s after %s", qtask.ID, delay, err)
+		return
+	}
+
+	if err := dw.env.Source.Ack(qtask); err != nil {
+		log.Errorf("worker: cannot ack task %d %s", qtask.ID, err)
+	}
+
+	dw.env.Results.Put(results.NewResult(qtask, sends, err, startedAt))
+}
+
+func (dw *defaultWorker) getTransport(platform task.Platform) transport.Transport {
+	sender, ok := dw.transports[platform]
+	if !ok {
+		sender = transport.GetTransport(platform)
+		if sender == nil {
+			return nil
+		}
+		sender = transport.Wrap(platform, sender)
+		dw.transports[platform] = sender
+	}
+	return sender
+}
+
+func (dw *defaultWorker) send(qtask *task.Task) error {
+	sender := dw.getTransport(qtask.Type)
+	if sender == nil {
+		log.Errorf("worker: unknown platform %s", qtask.Type)
+		return push.ErrorRequest
+	}
+	return sender.Send(qtask)
+}
+
+// render replaces task payload with rendered template
+func (dw *defaultWorker) render(qtask *task.Task) error {
+	if qtask.Template == "" {
+		return nil
+	}
+
+	base, _ := push.ParseMessage(qtask.Payload)
+
+	msg, err := templates.Apply(templates.Default(), qtask.Template, qtask.Locale, qtask.Variables, base)
+	if err != nil {
+		log.Errorf("worker: task %d cannot render template %s", qtask.ID, err)
+		return push.ErrorRequest
+	}
+
+	qtask.Payload = msg
+	return nil
+}
+
+// rawCrossPlatform reports provider specific payload is addressed to another platform,
+// fallback across platforms requires unified message or template
+func rawCrossPlatform(qtask *task.Task, target task.Target) bool {
+	if target.Type == qtask.Type {
+		return false
+	}
+	_, ok := push.ParseMessage(qtask.Payload)
+	return !ok
+}
+
+// push walks the task targets until one accepts the message or fails temporarily,
+// returns number of sends made
+func (dw *defaultWorker) push(qtask *task.Task) (sends int, err error) {
+	if err = dw.render(qtask); err != nil {
+		return
+	}
+	dw.limitTtl(qtask)
+
+	for i, target := range qtask.Targets() {
+		if i > 0 {
+			log.Infof("worker: task %d fallback to %s after %s", qtask.ID, target.Type, err)
+		}
+
+		targetTask := qtask.ForTarget(target)
+
+		if entry, ok := dw.env.Suppress.Suppressed(qtask.Project, target); ok {
+			log.Infof("worker: task %d %s %s suppressed by %s", qtask.ID, target.Type, target.To, entry.Reason)
+			err = push.ErrorSuppressed
+		} else if rawCrossPlatform(qtask, target) {
+			log.Errorf("worker: task %d raw %s payload cannot fall back to %s, use unified message or template", qtask.ID, qtask.Type, target.Type)
+			err = push.ErrorRequest
+		} else if err = validate.Task(targetTask); err != nil {
+			log.Errorf("worker: task %d invalid for %s %s", qtask.ID, target.Type, err)
+		} else {
+			slot, ok := dw.env.Concurrency.Acquire(qtask.Project, target.Type)
+			if !ok {
+				log.Debugf("worker: task %d %s concurrency limit reached", qtask.ID, target.Type)
+				return sends, push.NewRetryError(push.ErrorRateLimit, config.GetDuration("concurrency.retry_delay"))
+			}
+			if wait, ok := dw.env.Limiter.Allow(qtask.Project, target.Type); !ok {
+				slot.Cancel()
+				log.Debugf("worker: task %d %s throttled for %s", qtask.ID, target.Type, wait)
+				return sends, push.NewRetryError(push.ErrorRateLimit, wait)
+			}
+			sends++
+			err = dw.send(targetTask)
+			slot.Done(err)
+		}
+
+		if err == nil {
+			qtask.Delivered = &target
+			qtask.MessageId = targetTask.MessageId
+			log.Debugf("worker: task %d delivered via %s", qtask.ID, target.Type)
+			if targetTask.Canonical != "" {
+				dw.env.Feedback.Report(qtask.Project, target, push.NewCanonicalError(targetTask.Canonical))
+			}
+			return
+		}
+
+		if errors.Is(err, push.ErrorTokenRemoved) {
+			dw.env.Suppress.Add(suppress.TokenRemoved(qtask.Project, target))
+			dw.env.Feedback.Report(qtask.Project, target, err)
+		}
+
+		if !push.IsPermanent(err) {
+			return
+		}
+	}
+
+	return
 }
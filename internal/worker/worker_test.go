@@ -0,0 +1,217 @@
+package worker
+
+import (
+	"errors"
+	"push-sender/internal/concurrency"
+	"push-sender/internal/push"
+	"push-sender/internal/ratelimit"
+	"push-sender/internal/results"
+	"push-sender/internal/suppress"
+	"push-sender/internal/task"
+	"push-sender/internal/transport"
+	"testing"
+	"time"
+)
+
+type fakeFetcher struct {
+	acked    []uint64
+	released map[uint64]time.Duration
+}
+
+func (f *fakeFetcher) Get() (*task.Task, error) {
+	return nil, nil
+}
+
+func (f *fakeFetcher) Ack(qtask *task.Task) error {
+	f.acked = append(f.acked, qtask.ID)
+	return nil
+}
+
+func (f *fakeFetcher) Release(qtask *task.Task, delay time.Duration) error {
+	f.released[qtask.ID] = delay
+	return nil
+}
+
+type fakeSink struct {
+	results []*results.Result
+}
+
+func (s *fakeSink) Put(result *results.Result) {
+	s.results = append(s.results, result)
+}
+
+func (s *fakeSink) Close() {}
+
+type fakeFeedback struct {
+	reported []task.Target
+	errs     []error
+}
+
+func (f *fakeFeedback) Report(project string, target task.Target, err error) {
+	f.reported = append(f.reported, target)
+	f.errs = append(f.errs, err)
+}
+
+func (f *fakeFeedback) Close() {}
+
+// fakeTransport replies with errors in order, then succeeds
+type fakeTransport struct {
+	errs      []error
+	sends     int
+	canonical string
+}
+
+func (t *fakeTransport) Send(qtask *task.Task) error {
+	t.sends++
+	if len(t.errs) == 0 {
+		qtask.MessageId = "id"
+		qtask.Canonical = t.canonical
+		return nil
+	}
+	err := t.errs[0]
+	t.errs = t.errs[1:]
+	return err
+}
+
+type fixture struct {
+	worker   *defaultWorker
+	fetcher  *fakeFetcher
+	sink     *fakeSink
+	feedback *fakeFeedback
+}
+
+func newFixture(transports map[task.Platform]transport.Transport) *fixture {
+	f := &fixture{
+		fetcher:  &fakeFetcher{released: make(map[uint64]time.Duration)},
+		sink:     &fakeSink{},
+		feedback: &fakeFeedback{},
+	}
+	env := &Env{
+		Source:      f.fetcher,
+		Results:     f.sink,
+		Feedback:    f.feedback,
+		Suppress:    suppress.NewDefaultStore(),
+		Limiter:     ratelimit.NewLimiter(),
+		Concurrency: concurrency.New(),
+	}
+	f.worker = NewDefaultWorker(env).(*defaultWorker)
+	f.worker.transports = transports
+	return f
+}
+
+func TestFallback(t *testing.T) {
+	android := &fakeTransport{errs: []error{push.ErrorInvalidKey}}
+	huawei := &fakeTransport{}
+	f := newFixture(map[task.Platform]transport.Transport{task.Android: android, task.Huawei: huawei})
+
+	f.worker.process(&task.Task{
+		ID:       1,
+		Type:     task.Android,
+		To:       "fcm-token",
+		Payload:  &push.Message{Title: "hi"},
+		Fallback: []task.Target{{Type: task.Huawei, To: "hms-token"}},
+	})
+
+	if android.sends != 1 || huawei.sends != 1 || len(f.fetcher.acked) != 1 {
+		t.Fatalf("fallback is not walked: android %d huawei %d acked %v", android.sends, huawei.sends, f.fetcher.acked)
+	}
+	r := f.sink.results[0]
+	if r.Platform != task.Huawei || r.To != "hms-token" || r.Code != "" || r.Sends != 2 || r.MessageId != "id" {
+		t.Errorf("bad result %#v", r)
+	}
+}
+
+func TestFallbackRawPayload(t *testing.T) {
+	android := &fakeTransport{errs: []error{push.ErrorInvalidKey}}
+	ios := &fakeTransport{}
+	f := newFixture(map[task.Platform]transport.Transport{task.Android: android, task.Ios: ios})
+
+	f.worker.process(&task.Task{
+		ID:       1,
+		Type:     task.Android,
+		To:       "fcm-token",
+		Payload:  map[string]string{"id": "1"},
+		Fallback: []task.Target{{Type: task.Ios, To: "apns-token"}},
+	})
+
+	if ios.sends != 0 || len(f.sink.results) != 1 || f.sink.results[0].Code != "InvalidRequest" {
+		t.Errorf("raw payload sent to other platform: ios %d results %v", ios.sends, f.sink.results)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	android := &fakeTransport{errs: []error{push.NewRetryError(push.ErrorRateLimit, 30*time.Second)}}
+	f := newFixture(map[task.Platform]transport.Transport{task.Android: android})
+
+	f.worker.process(&task.Task{ID: 2, Type: task.Android, To: "token", Payload: map[string]string{"id": "1"}})
+
+	if f.fetcher.released[2] != 30*time.Second || len(f.fetcher.acked) != 0 || len(f.sink.results) != 0 {
+		t.Errorf("task is not released after retry error: released %v acked %v", f.fetcher.released, f.fetcher.acked)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	android := &fakeTransport{}
+	f := newFixture(map[task.Platform]transport.Transport{task.Android: android})
+
+	f.worker.process(&task.Task{
+		ID:        3,
+		Type:      task.Android,
+		To:        "token",
+		Payload:   map[string]string{"id": "1"},
+		ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	})
+
+	if android.sends != 0 || len(f.fetcher.acked) != 1 {
+		t.Fatalf("expired task is sent %d or not acked %v", android.sends, f.fetcher.acked)
+	}
+	if r := f.sink.results[0]; r.Code != "Expired" || r.Sends != 0 {
+		t.Errorf("bad result %#v", r)
+	}
+	if !push.IsPermanent(push.ErrorExpired) {
+		t.Error("expired task would be retried")
+	}
+}
+
+func TestSuppressTokenRemoved(t *testing.T) {
+	android := &fakeTransport{errs: []error{push.ErrorTokenRemoved}}
+	f := newFixture(map[task.Platform]transport.Transport{task.Android: android})
+
+	qtask := &task.Task{ID: 4, Project: "shop", Type: task.Android, To: "dead-token", Payload: map[string]string{"id": "1"}}
+	f.worker.process(qtask)
+
+	target := task.Target{Type: task.Android, To: "dead-token"}
+	if len(f.feedback.reported) != 1 || f.feedback.reported[0] != target {
+		t.Fatalf("removed token is not reported %v", f.feedback.reported)
+	}
+	if _, ok := f.worker.env.Suppress.Suppressed("shop", target); !ok {
+		t.Fatal("removed token is not suppressed")
+	}
+
+	next := &task.Task{ID: 5, Project: "shop", Type: task.Android, To: "dead-token", Payload: map[string]string{"id": "2"}}
+	f.worker.process(next)
+
+	if android.sends != 1 {
+		t.Errorf("suppressed token is sent %d times", android.sends)
+	}
+	if r := f.sink.results[1]; !errors.Is(push.ErrorSuppressed, push.PushError(r.Code)) || r.Sends != 0 {
+		t.Errorf("bad result %#v", r)
+	}
+}
+
+func TestCanonicalFeedback(t *testing.T) {
+	amazon := &fakeTransport{canonical: "new-token"}
+	f := newFixture(map[task.Platform]transport.Transport{task.Amazon: amazon})
+
+	f.worker.process(&task.Task{ID: 6, Project: "shop", Type: task.Amazon, To: "old-token", Payload: map[string]string{"id": "1"}})
+
+	if len(f.feedback.reported) != 1 || f.feedback.reported[0].To != "old-token" {
+		t.Fatalf("canonical id is not reported %v", f.feedback.reported)
+	}
+	if to, ok := push.CanonicalTo(f.feedback.errs[0]); !ok || to != "new-token" {
+		t.Errorf("bad replacement %v", f.feedback.errs[0])
+	}
+	if r := f.sink.results[0]; r.Code != "" {
+		t.Errorf("delivered task has code %s", r.Code)
+	}
+}